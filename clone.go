@@ -0,0 +1,108 @@
+package mergo
+
+import (
+	"reflect"
+)
+
+// Clone returns a deep copy of v that shares no pointers, maps or slices with it.
+// Self-referential values are copied once, keeping the same shape in the copy.
+// Unexported struct fields cannot be reached through reflection, so they are copied
+// as they are and may still share memory with v.
+func Clone(v interface{}) interface{} {
+	if v == nil {
+		return nil
+	}
+	return deepCopyValue(reflect.ValueOf(v), map[cloneKey]reflect.Value{}).Interface()
+}
+
+// copyValue returns src, or a deep copy of it when the merge runs WithDeepCopy.
+func copyValue(src reflect.Value, config *Config) reflect.Value {
+	if !config.deepCopy || !src.IsValid() {
+		return src
+	}
+	return deepCopyValue(src, map[cloneKey]reflect.Value{})
+}
+
+// cloneKey identifies a pointer, map or slice already copied; slices sharing a backing
+// array but differing in length are different values.
+type cloneKey struct {
+	ptr uintptr
+	typ reflect.Type
+	len int
+}
+
+func deepCopyValue(src reflect.Value, seen map[cloneKey]reflect.Value) reflect.Value {
+	switch src.Kind() {
+	case reflect.Ptr:
+		if src.IsNil() {
+			return src
+		}
+		key := cloneKey{src.Pointer(), src.Type(), 0}
+		if c, ok := seen[key]; ok {
+			return c
+		}
+		c := reflect.New(src.Type().Elem())
+		seen[key] = c
+		copyInto(c.Elem(), src.Elem(), seen)
+		return c
+	case reflect.Map:
+		if src.IsNil() {
+			return src
+		}
+		key := cloneKey{src.Pointer(), src.Type(), 0}
+		if c, ok := seen[key]; ok {
+			return c
+		}
+		c := reflect.MakeMapWithSize(src.Type(), src.Len())
+		seen[key] = c
+		for _, k := range src.MapKeys() {
+			c.SetMapIndex(deepCopyValue(k, seen), deepCopyValue(src.MapIndex(k), seen))
+		}
+		return c
+	case reflect.Slice:
+		if src.IsNil() {
+			return src
+		}
+		key := cloneKey{src.Pointer(), src.Type(), src.Len()}
+		if c, ok := seen[key]; ok {
+			return c
+		}
+		c := reflect.MakeSlice(src.Type(), src.Len(), src.Len())
+		seen[key] = c
+		for i := 0; i < src.Len(); i++ {
+			copyInto(c.Index(i), src.Index(i), seen)
+		}
+		return c
+	case reflect.Interface:
+		if src.IsNil() {
+			return src
+		}
+		c := reflect.New(src.Type()).Elem()
+		c.Set(deepCopyValue(src.Elem(), seen))
+		return c
+	case reflect.Struct, reflect.Array:
+		c := reflect.New(src.Type()).Elem()
+		copyInto(c, src, seen)
+		return c
+	}
+	return src
+}
+
+// copyInto deep copies src into the settable dst.
+func copyInto(dst, src reflect.Value, seen map[cloneKey]reflect.Value) {
+	switch src.Kind() {
+	case reflect.Struct:
+		dst.Set(src)
+		for i, n := 0, dst.NumField(); i < n; i++ {
+			if dst.Field(i).CanSet() {
+				copyInto(dst.Field(i), src.Field(i), seen)
+			}
+		}
+	case reflect.Array:
+		for i := 0; i < src.Len(); i++ {
+			copyInto(dst.Index(i), src.Index(i), seen)
+		}
+	default:
+		dst.Set(deepCopyValue(src, seen))
+	}
+}
@@ -0,0 +1,89 @@
+package mergo
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/davecgh/go-spew/spew"
+)
+
+type cloneTestNode struct {
+	Name     string
+	Next     *cloneTestNode
+	Children []*cloneTestNode
+	Attrs    map[string]interface{}
+	ids      []int
+}
+
+type cloneTestConfig struct {
+	Hosts   []string
+	Labels  map[string]string
+	Timeout *int
+	Nested  map[string]interface{}
+}
+
+func TestClone(t *testing.T) {
+	root := &cloneTestNode{Name: `root`, Attrs: map[string]interface{}{`tags`: []string{`a`}}, ids: []int{1}}
+	child := &cloneTestNode{Name: `child`, Next: root}
+	root.Next = root
+	root.Children = []*cloneTestNode{child, child}
+
+	c, ok := Clone(root).(*cloneTestNode)
+	if !ok {
+		t.Fatalf(`expected *cloneTestNode, got %T`, Clone(root))
+	}
+	if c == root || c.Next != c || c.Children[0] != c.Children[1] || c.Children[0].Next != c {
+		t.Fatal(`Clone did not preserve the shape of the self-referential graph`)
+	}
+	if c.Children[0] == child {
+		t.Fatal(`Clone shared a pointer with the source`)
+	}
+	c.Attrs[`tags`].([]string)[0] = `changed`
+	c.Children[0].Name = `changed`
+	if root.Attrs[`tags`].([]string)[0] != `a` || child.Name != `child` {
+		spew.Dump(root)
+		t.Fatal(`mutating the clone changed the source`)
+	}
+	if !reflect.DeepEqual(c.ids, root.ids) {
+		t.Fatal(`Clone did not keep unexported fields`)
+	}
+}
+
+func TestMergeWithDeepCopy(t *testing.T) {
+	timeout := 30
+	newSrc := func() cloneTestConfig {
+		return cloneTestConfig{
+			Hosts:   []string{`a`, `b`},
+			Labels:  map[string]string{`team`: `core`},
+			Timeout: &timeout,
+			Nested:  map[string]interface{}{`list`: []interface{}{`x`}},
+		}
+	}
+
+	src := newSrc()
+	shared := cloneTestConfig{}
+	if err := Merge(&shared, src); err != nil {
+		t.Fatal(`error running Merge: ` + err.Error())
+	}
+	if &shared.Hosts[0] != &src.Hosts[0] {
+		t.Fatal(`expected Merge without WithDeepCopy to share the slice`)
+	}
+
+	dst := cloneTestConfig{Nested: map[string]interface{}{}}
+	if err := Merge(&dst, src, WithDeepCopy); err != nil {
+		t.Fatal(`error running Merge: ` + err.Error())
+	}
+	src.Hosts[0] = `changed`
+	src.Labels[`team`] = `changed`
+	*src.Timeout = 0
+	src.Nested[`list`].([]interface{})[0] = `changed`
+
+	want := newSrc()
+	fresh := 30
+	want.Timeout = &fresh
+	want.Nested = map[string]interface{}{`list`: []interface{}{`x`}}
+	if !reflect.DeepEqual(dst, want) {
+		spew.Dump(dst)
+		t.Fatal(`mutating src after Merge WithDeepCopy leaked into dst`)
+	}
+}
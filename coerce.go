@@ -0,0 +1,244 @@
+package mergo
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// parseValue parses s into a value of typ, which must be a string, bool, number or
+// time.Duration type. This is the parsing used for environment variable overrides.
+func parseValue(s string, typ reflect.Type) (reflect.Value, error) {
+	v := reflect.New(typ).Elem()
+	if typ == durationType {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return v, err
+		}
+		v.SetInt(int64(d))
+		return v, nil
+	}
+	switch typ.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return v, err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(s, 10, typ.Bits())
+		if err != nil {
+			return v, err
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		u, err := strconv.ParseUint(s, 10, typ.Bits())
+		if err != nil {
+			return v, err
+		}
+		v.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, typ.Bits())
+		if err != nil {
+			return v, err
+		}
+		v.SetFloat(f)
+	default:
+		return v, fmt.Errorf("cannot parse %q into %s", s, typ)
+	}
+	return v, nil
+}
+
+// isParseableType reports whether parseValue can parse a value of typ.
+func isParseableType(typ reflect.Type) bool {
+	switch k := typ.Kind(); k {
+	case reflect.String, reflect.Bool, reflect.Float32, reflect.Float64:
+		return true
+	default:
+		return isIntKind(k) || isUintKind(k)
+	}
+}
+
+// parseFieldValue parses s into a value of typ, a type parseValue handles, a pointer to
+// one, an Opt of one or a slice of them given as comma-separated values.
+func parseFieldValue(s string, typ reflect.Type) (reflect.Value, error) {
+	switch {
+	case isOptionalType(typ):
+		opt := reflect.New(typ)
+		v, err := parseValue(s, leafType(typ))
+		if err != nil {
+			return v, err
+		}
+		opt.Interface().(optional).setOptional(v)
+		return opt.Elem(), nil
+	case typ.Kind() == reflect.Ptr:
+		v, err := parseValue(s, typ.Elem())
+		if err != nil {
+			return v, err
+		}
+		p := reflect.New(typ.Elem())
+		p.Elem().Set(v)
+		return p, nil
+	case typ.Kind() == reflect.Slice:
+		parts := strings.Split(s, `,`)
+		slice := reflect.MakeSlice(typ, len(parts), len(parts))
+		for i, part := range parts {
+			v, err := parseValue(strings.TrimSpace(part), typ.Elem())
+			if err != nil {
+				return v, err
+			}
+			slice.Index(i).Set(v)
+		}
+		return slice, nil
+	}
+	return parseValue(s, typ)
+}
+
+// leafType returns the type parseFieldValue parses for a value of typ: the value type
+// of an Opt, and the element type of a pointer or slice.
+func leafType(typ reflect.Type) reflect.Type {
+	if isOptionalType(typ) {
+		return reflect.New(typ).Interface().(optional).optionalType()
+	}
+	if typ.Kind() == reflect.Ptr || (typ.Kind() == reflect.Slice && typ.Elem().Kind() != reflect.Uint8) {
+		return typ.Elem()
+	}
+	return typ
+}
+
+// coerceValue converts v into a value of typ the way WithWeaklyTypedInput allows:
+// strings, numbers and bools into each other, floats into integers when no precision
+// is lost, strings into time.Duration and single values into one-element slices.
+// Pointer types are filled with a newly allocated value.
+func coerceValue(v reflect.Value, typ reflect.Type) (reflect.Value, bool) {
+	if v.Type().AssignableTo(typ) {
+		return v, true
+	}
+	if typ.Kind() == reflect.Ptr && v.Kind() != reflect.Ptr {
+		c, ok := coerceValue(v, typ.Elem())
+		if !ok {
+			return v, false
+		}
+		p := reflect.New(typ.Elem())
+		p.Elem().Set(c)
+		return p, true
+	}
+	if typ.Kind() == reflect.Slice && typ.Elem().Kind() != reflect.Uint8 {
+		if v.Kind() == reflect.Slice || v.Kind() == reflect.Array {
+			s := reflect.MakeSlice(typ, v.Len(), v.Len())
+			for i := 0; i < v.Len(); i++ {
+				e := v.Index(i)
+				if e.Kind() == reflect.Interface && !e.IsNil() {
+					e = e.Elem()
+				}
+				c, ok := coerceValue(e, typ.Elem())
+				if !ok {
+					return v, false
+				}
+				s.Index(i).Set(c)
+			}
+			return s, true
+		}
+		c, ok := coerceValue(v, typ.Elem())
+		if !ok {
+			return v, false
+		}
+		return reflect.Append(reflect.MakeSlice(typ, 0, 1), c), true
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		c, err := parseValue(v.String(), typ)
+		return c, err == nil
+	case reflect.Bool:
+		switch {
+		case typ.Kind() == reflect.String:
+			return reflect.ValueOf(strconv.FormatBool(v.Bool())).Convert(typ), true
+		case isNumberKind(typ.Kind()):
+			n := 0
+			if v.Bool() {
+				n = 1
+			}
+			return coerceValue(reflect.ValueOf(n), typ)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i := v.Int()
+		switch {
+		case typ.Kind() == reflect.String && v.Type() != durationType:
+			return reflect.ValueOf(strconv.FormatInt(i, 10)).Convert(typ), true
+		case typ.Kind() == reflect.Bool:
+			return reflect.ValueOf(i != 0).Convert(typ), true
+		case isUintKind(typ.Kind()):
+			if i < 0 || reflect.Zero(typ).OverflowUint(uint64(i)) {
+				return v, false
+			}
+			return v.Convert(typ), true
+		case isNumberKind(typ.Kind()):
+			if isIntKind(typ.Kind()) && reflect.Zero(typ).OverflowInt(i) {
+				return v, false
+			}
+			return v.Convert(typ), true
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		u := v.Uint()
+		switch {
+		case typ.Kind() == reflect.String:
+			return reflect.ValueOf(strconv.FormatUint(u, 10)).Convert(typ), true
+		case typ.Kind() == reflect.Bool:
+			return reflect.ValueOf(u != 0).Convert(typ), true
+		case isIntKind(typ.Kind()):
+			if u > math.MaxInt64 || reflect.Zero(typ).OverflowInt(int64(u)) {
+				return v, false
+			}
+			return v.Convert(typ), true
+		case isNumberKind(typ.Kind()):
+			if isUintKind(typ.Kind()) && reflect.Zero(typ).OverflowUint(u) {
+				return v, false
+			}
+			return v.Convert(typ), true
+		}
+	case reflect.Float32, reflect.Float64:
+		f := v.Float()
+		switch {
+		case typ.Kind() == reflect.String:
+			return reflect.ValueOf(strconv.FormatFloat(f, 'f', -1, v.Type().Bits())).Convert(typ), true
+		case typ.Kind() == reflect.Bool:
+			return reflect.ValueOf(f != 0).Convert(typ), true
+		case isIntKind(typ.Kind()):
+			if f != math.Trunc(f) || f < math.MinInt64 || f >= math.MaxInt64 || reflect.Zero(typ).OverflowInt(int64(f)) {
+				return v, false
+			}
+			return reflect.ValueOf(int64(f)).Convert(typ), true
+		case isUintKind(typ.Kind()):
+			if f != math.Trunc(f) || f < 0 || f >= math.MaxUint64 || reflect.Zero(typ).OverflowUint(uint64(f)) {
+				return v, false
+			}
+			return reflect.ValueOf(uint64(f)).Convert(typ), true
+		case isNumberKind(typ.Kind()):
+			return v.Convert(typ), true
+		}
+	}
+	if v.Kind() == typ.Kind() && v.Type().ConvertibleTo(typ) && v.Kind() != reflect.Struct {
+		return v.Convert(typ), true
+	}
+	return v, false
+}
+
+func isIntKind(k reflect.Kind) bool {
+	return k >= reflect.Int && k <= reflect.Int64
+}
+
+func isUintKind(k reflect.Kind) bool {
+	return k >= reflect.Uint && k <= reflect.Uintptr
+}
+
+func isNumberKind(k reflect.Kind) bool {
+	return k >= reflect.Int && k <= reflect.Float64
+}
@@ -0,0 +1,48 @@
+package mergo
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCoerceValue(t *testing.T) {
+	one := 1
+	tests := []struct {
+		name string
+		in   interface{}
+		typ  reflect.Type
+		want interface{}
+		ok   bool
+	}{
+		{`string to int`, `8080`, reflect.TypeOf(0), 8080, true},
+		{`bad string to int`, `http`, reflect.TypeOf(0), nil, false},
+		{`string to bool`, `true`, reflect.TypeOf(false), true, true},
+		{`string to float`, `1.5`, reflect.TypeOf(0.0), 1.5, true},
+		{`string to duration`, `30s`, durationType, 30 * time.Second, true},
+		{`lossless float to int`, 8080.0, reflect.TypeOf(0), 8080, true},
+		{`lossy float to int`, 1.5, reflect.TypeOf(0), nil, false},
+		{`negative float to uint`, -1.0, reflect.TypeOf(uint(0)), nil, false},
+		{`overflowing int`, 300, reflect.TypeOf(int8(0)), nil, false},
+		{`int to string`, 42, reflect.TypeOf(``), `42`, true},
+		{`float to string`, 0.25, reflect.TypeOf(``), `0.25`, true},
+		{`bool to string`, false, reflect.TypeOf(``), `false`, true},
+		{`int to bool`, 2, reflect.TypeOf(false), true, true},
+		{`bool to int`, true, reflect.TypeOf(0), 1, true},
+		{`int to pointer`, `1`, reflect.TypeOf(&one), &one, true},
+		{`value to slice`, `a`, reflect.TypeOf([]string{}), []string{`a`}, true},
+		{`slice elements`, []interface{}{`1`, 2.0}, reflect.TypeOf([]int{}), []int{1, 2}, true},
+		{`map to int`, map[string]interface{}{}, reflect.TypeOf(0), nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := coerceValue(reflect.ValueOf(tt.in), tt.typ)
+			if ok != tt.ok {
+				t.Fatalf(`expected ok=%v, got %v`, tt.ok, ok)
+			}
+			if ok && !reflect.DeepEqual(got.Interface(), tt.want) {
+				t.Fatalf(`expected %#v, got %#v`, tt.want, got.Interface())
+			}
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package mergo
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// ApplyDefaults fills the empty fields of the struct dst points to from their `default`
+// tags, e.g. `default:"30s"` on a time.Duration, parsed as environment overrides are.
+// Pointers get a new value and Opt fields are set; slices take comma-separated values,
+// e.g. `default:"a,b"`. Nested structs are filled too, including the elements of slices
+// of structs, and a nil pointer to a struct is allocated if a default applies inside it.
+func ApplyDefaults(dst interface{}) error {
+	if dst == nil {
+		return ErrNilArguments
+	}
+	vDst := reflect.ValueOf(dst)
+	if vDst.Kind() != reflect.Ptr || vDst.IsNil() {
+		return ErrNonPointerAgument
+	}
+	if vDst.Elem().Kind() != reflect.Struct {
+		return ErrExpectedStructAsDestination
+	}
+	return applyDefaults(vDst.Elem(), ``, &Config{})
+}
+
+func applyDefaults(v reflect.Value, path string, config *Config) error {
+	d := &defaulter{config: config, seen: map[uintptr]bool{}, filling: map[reflect.Type]bool{}}
+	_, err := d.fill(v, path)
+	return err
+}
+
+type defaulter struct {
+	config  *Config
+	seen    map[uintptr]bool
+	filling map[reflect.Type]bool
+}
+
+// fill sets the defaults of the empty fields inside v and reports whether it set any.
+func (d *defaulter) fill(v reflect.Value, path string) (filled bool, err error) {
+	switch v.Kind() {
+	case reflect.Struct:
+		if isOptionalType(v.Type()) {
+			return false, nil
+		}
+		// a nil pointer to a struct being filled, as in a linked list, is left nil
+		if !d.filling[v.Type()] {
+			d.filling[v.Type()] = true
+			defer delete(d.filling, v.Type())
+		}
+		for i, n := 0, v.NumField(); i < n; i++ {
+			sf := v.Type().Field(i)
+			if !isExportedComponent(&sf) {
+				continue
+			}
+			fv := v.Field(i)
+			fp := path
+			if !sf.Anonymous {
+				fp = joinFieldPath(path, sf.Name)
+			}
+			if def := parseField(sf).Default; def != `` {
+				if !isEmpty(fv, d.config) {
+					continue
+				}
+				value, err := parseFieldValue(def, sf.Type)
+				if err != nil {
+					return filled, fmt.Errorf("invalid default for %s: %w", fp, err)
+				}
+				fv.Set(value)
+				filled = true
+				continue
+			}
+			f, err := d.fill(fv, fp)
+			filled = filled || f
+			if err != nil {
+				return filled, err
+			}
+		}
+	case reflect.Ptr:
+		elem := v.Type().Elem()
+		if elem.Kind() != reflect.Struct {
+			return false, nil
+		}
+		if !v.IsNil() {
+			if d.seen[v.Pointer()] {
+				return false, nil
+			}
+			d.seen[v.Pointer()] = true
+			return d.fill(v.Elem(), path)
+		}
+		if !v.CanSet() || d.filling[elem] {
+			return false, nil
+		}
+		p := reflect.New(elem)
+		if filled, err = d.fill(p.Elem(), path); filled && err == nil {
+			v.Set(p)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			f, err := d.fill(v.Index(i), joinIndexPath(path, i))
+			filled = filled || f
+			if err != nil {
+				return filled, err
+			}
+		}
+	}
+	return filled, err
+}
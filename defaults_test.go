@@ -0,0 +1,89 @@
+package mergo
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/davecgh/go-spew/spew"
+)
+
+type defaultsTestConfig struct {
+	Timeout  time.Duration `default:"30s"`
+	Port     int           `default:"8080"`
+	Name     string        `default:"api"`
+	Verbose  *bool         `default:"true"`
+	Retries  Opt[int]      `default:"0"`
+	Hosts    []string      `default:"a, b"`
+	Database *defaultsTestDatabase
+	Cache    *defaultsTestCache
+	Replicas []defaultsTestReplica
+	Next     *defaultsTestConfig
+}
+
+type defaultsTestDatabase struct {
+	Host     string `default:"localhost"`
+	MaxConns uint   `default:"10"`
+}
+
+type defaultsTestCache struct {
+	Size int
+}
+
+type defaultsTestReplica struct {
+	Zone   string
+	Weight float64 `default:"1.5"`
+}
+
+func TestApplyDefaults(t *testing.T) {
+	cfg := defaultsTestConfig{
+		Port:     9090,
+		Replicas: []defaultsTestReplica{{Zone: `a`}, {Zone: `b`, Weight: 2}},
+	}
+	if err := ApplyDefaults(&cfg); err != nil {
+		t.Fatal(`error running ApplyDefaults: ` + err.Error())
+	}
+	verbose := true
+	want := defaultsTestConfig{
+		Timeout:  30 * time.Second,
+		Port:     9090,
+		Name:     `api`,
+		Verbose:  &verbose,
+		Retries:  Some(0),
+		Hosts:    []string{`a`, `b`},
+		Database: &defaultsTestDatabase{Host: `localhost`, MaxConns: 10},
+		Replicas: []defaultsTestReplica{{Zone: `a`, Weight: 1.5}, {Zone: `b`, Weight: 2}},
+	}
+	if !reflect.DeepEqual(cfg, want) {
+		spew.Dump(cfg)
+		t.Fatal(`ApplyDefaults did not fill the empty fields`)
+	}
+
+	type invalid struct {
+		Port int `default:"eighty"`
+	}
+	if err := ApplyDefaults(&invalid{}); err == nil {
+		t.Fatal(`expected an error for an invalid default`)
+	}
+}
+
+func TestMergeWithDefaults(t *testing.T) {
+	dst := defaultsTestConfig{Name: `dst`}
+	src := defaultsTestConfig{Port: 9090}
+	if err := Merge(&dst, src, WithDefaults); err != nil {
+		t.Fatal(`error running Merge: ` + err.Error())
+	}
+	if dst.Name != `dst` || dst.Port != 9090 || dst.Timeout != 30*time.Second || dst.Database == nil {
+		spew.Dump(dst)
+		t.Fatal(`Merge WithDefaults did not fill the fields left empty`)
+	}
+
+	var mapped defaultsTestConfig
+	if err := Map(&mapped, map[string]interface{}{`port`: 1}, WithDefaults); err != nil {
+		t.Fatal(`error running Map: ` + err.Error())
+	}
+	if mapped.Port != 1 || mapped.Name != `api` {
+		spew.Dump(mapped)
+		t.Fatal(`Map WithDefaults did not fill the fields left empty`)
+	}
+}
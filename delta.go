@@ -0,0 +1,155 @@
+package mergo
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// ErrDeltaNotRepresentable is returned by Delta when some change cannot be expressed
+// as an override, e.g. setting a field back to its empty value or removing a map key.
+var ErrDeltaNotRepresentable = errors.New("change cannot be expressed as an override")
+
+// Delta is the inverse of Merge: it returns a sparse value of the same type as target,
+// holding only what differs from base, such that Merge(&base, delta, WithOverride)
+// yields target. If target is a pointer, a pointer is returned.
+// Merge never applies empty src values, never removes map keys and never touches
+// final fields, so changes of that kind make Delta fail with ErrDeltaNotRepresentable,
+// listing the paths involved.
+func Delta(base, target interface{}) (interface{}, error) {
+	if base == nil || target == nil {
+		return nil, ErrNilArguments
+	}
+	vBase, vTarget := reflect.ValueOf(base), reflect.ValueOf(target)
+	isPtr := vTarget.Kind() == reflect.Ptr
+	if vBase.Kind() == reflect.Ptr {
+		vBase = vBase.Elem()
+	}
+	if isPtr {
+		vTarget = vTarget.Elem()
+	}
+	if !vBase.IsValid() || !vTarget.IsValid() {
+		return nil, ErrNilArguments
+	}
+	if vBase.Type() != vTarget.Type() {
+		return nil, ErrDifferentArgumentsTypes
+	}
+	if vTarget.Kind() != reflect.Struct && vTarget.Kind() != reflect.Map {
+		return nil, ErrNotSupported
+	}
+	out := reflect.New(vTarget.Type())
+	d := &deltaBuilder{}
+	d.delta(out.Elem(), vBase, vTarget, ``)
+	if len(d.unrepresentable) > 0 {
+		return nil, fmt.Errorf("%w: %s", ErrDeltaNotRepresentable, strings.Join(d.unrepresentable, `, `))
+	}
+	if isPtr {
+		return out.Interface(), nil
+	}
+	return out.Elem().Interface(), nil
+}
+
+type deltaBuilder struct {
+	unrepresentable []string
+}
+
+func (d *deltaBuilder) fail(path string) {
+	if path == `` {
+		path = `.`
+	}
+	d.unrepresentable = append(d.unrepresentable, path)
+}
+
+// delta stores in dst (a zero value) what Merge needs to turn base into target.
+func (d *deltaBuilder) delta(dst, base, target reflect.Value, path string) {
+	// an embedded struct of an unexported type can't be compared as a whole, but its
+	// exported fields are walked as the others
+	if target.CanInterface() && reflect.DeepEqual(base.Interface(), target.Interface()) {
+		// Merge copies structs without exported fields unless they are empty by an IsZero
+		// method, so a zero value in the delta would clobber base.
+		if target.Kind() == reflect.Struct && !hasMergeableFields(target) && !base.IsZero() && !isEmptyValue(reflect.Zero(target.Type())) {
+			d.fail(path)
+		}
+		return
+	}
+	switch target.Kind() {
+	case reflect.Struct:
+		if !hasMergeableFields(target) {
+			break
+		}
+		for i, n := 0, target.NumField(); i < n; i++ {
+			sf := target.Type().Field(i)
+			if sf.Anonymous && sf.Type.Kind() == reflect.Struct {
+				// Merge can't set an embedded struct of an unexported type without exported
+				// fields, so it has no place in the delta
+				if !target.Field(i).CanInterface() && !hasMergeableFields(target.Field(i)) {
+					continue
+				}
+				d.delta(dst.Field(i), base.Field(i), target.Field(i), path)
+				continue
+			}
+			if !isExportedComponent(&sf) {
+				continue
+			}
+			fp := joinFieldPath(path, sf.Name)
+			if parseField(sf).Final {
+				if !reflect.DeepEqual(base.Field(i).Interface(), target.Field(i).Interface()) {
+					d.fail(fp)
+				}
+				continue
+			}
+			d.delta(dst.Field(i), base.Field(i), target.Field(i), fp)
+		}
+		return
+	case reflect.Ptr:
+		if base.IsNil() || target.IsNil() {
+			break
+		}
+		dst.Set(reflect.New(target.Type().Elem()))
+		d.delta(dst.Elem(), base.Elem(), target.Elem(), path)
+		return
+	case reflect.Map:
+		if base.IsNil() || target.IsNil() {
+			break
+		}
+		dst.Set(reflect.MakeMap(target.Type()))
+		for _, key := range unionMapKeys(base, target) {
+			be, te := base.MapIndex(key), target.MapIndex(key)
+			kp := joinIndexPath(path, key.Interface())
+			switch {
+			case !te.IsValid():
+				d.fail(kp)
+			case !be.IsValid() || !reflect.DeepEqual(be.Interface(), te.Interface()):
+				if be.IsValid() {
+					d.droppedKeys(be, te, kp)
+				}
+				dst.SetMapIndex(key, target.MapIndex(key))
+			}
+		}
+		return
+	}
+	if isEmptyValue(target) {
+		d.fail(path)
+		return
+	}
+	dst.Set(target)
+}
+
+// droppedKeys fails the keys of base missing from target, at any depth of nested maps,
+// since Merge merges nested maps key by key and never removes one.
+func (d *deltaBuilder) droppedKeys(base, target reflect.Value, path string) {
+	base, target = reflect.ValueOf(base.Interface()), reflect.ValueOf(target.Interface())
+	if base.Kind() != reflect.Map || target.Kind() != reflect.Map {
+		return
+	}
+	for _, k := range base.MapKeys() {
+		kp := joinIndexPath(path, k.Interface())
+		te := target.MapIndex(k)
+		if !te.IsValid() {
+			d.fail(kp)
+			continue
+		}
+		d.droppedKeys(base.MapIndex(k), te, kp)
+	}
+}
@@ -0,0 +1,151 @@
+package mergo
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/davecgh/go-spew/spew"
+)
+
+type deltaTestDatabase struct {
+	Host string
+	Port int
+}
+
+type deltaTestConfig struct {
+	RequiredConfig
+	Database *deltaTestDatabase
+	Labels   map[string]string
+	Hosts    []string
+	Nested   map[string]interface{}
+}
+
+func newDeltaTestConfig() deltaTestConfig {
+	return deltaTestConfig{
+		RequiredConfig: RequiredConfig{OverrideConfigPath: `/etc/override.yml`, Environment: `dev`, LogLevel: `info`},
+		Database:       &deltaTestDatabase{`db`, 5432},
+		Labels:         map[string]string{`team`: `core`},
+		Hosts:          []string{`a`},
+		Nested:         map[string]interface{}{`a`: map[string]interface{}{`b`: 1}},
+	}
+}
+
+func TestDelta(t *testing.T) {
+	base := newDeltaTestConfig()
+	target := newDeltaTestConfig()
+	target.Environment = `prod`
+	target.Database.Host = `prod-db`
+	target.Labels[`tier`] = `web`
+	target.Hosts = []string{`a`, `b`}
+	target.Nested = map[string]interface{}{`a`: map[string]interface{}{`b`: 2}}
+
+	delta, err := Delta(base, &target)
+	if err != nil {
+		t.Fatal(`error running Delta: ` + err.Error())
+	}
+	sparse, ok := delta.(*deltaTestConfig)
+	if !ok {
+		t.Fatalf(`expected *deltaTestConfig, got %T`, delta)
+	}
+	want := deltaTestConfig{
+		RequiredConfig: RequiredConfig{Environment: `prod`},
+		Database:       &deltaTestDatabase{Host: `prod-db`},
+		Labels:         map[string]string{`tier`: `web`},
+		Hosts:          []string{`a`, `b`},
+		Nested:         map[string]interface{}{`a`: map[string]interface{}{`b`: 2}},
+	}
+	if !reflect.DeepEqual(*sparse, want) {
+		spew.Dump(sparse)
+		t.Fatal(`Delta did not produce the expected sparse value`)
+	}
+
+	if err = Merge(&base, sparse, WithOverride); err != nil {
+		t.Fatal(`error running Merge: ` + err.Error())
+	}
+	if !reflect.DeepEqual(base, target) {
+		spew.Dump(base, target)
+		t.Fatal(`merging the delta did not yield the target`)
+	}
+}
+
+func TestDeltaNotRepresentable(t *testing.T) {
+	base := newDeltaTestConfig()
+	target := newDeltaTestConfig()
+	target.LogLevel = ``
+	target.OverrideConfigPath = `/tmp/other.yml`
+	delete(target.Labels, `team`)
+	base.Nested = map[string]interface{}{`a`: map[string]interface{}{`b`: map[string]interface{}{`c`: 1, `d`: 2}}}
+	target.Nested = map[string]interface{}{`a`: map[string]interface{}{`b`: map[string]interface{}{`c`: 1}}}
+
+	_, err := Delta(base, target)
+	if !errors.Is(err, ErrDeltaNotRepresentable) {
+		t.Fatalf(`expected %v, got %v`, ErrDeltaNotRepresentable, err)
+	}
+	want := ErrDeltaNotRepresentable.Error() + `: OverrideConfigPath, LogLevel, Labels[team], Nested[a][b][d]`
+	if err.Error() != want {
+		t.Fatalf(`expected %q, got %q`, want, err.Error())
+	}
+}
+
+type deltaTestEmbedded struct {
+	Host string
+	Port int
+}
+
+type deltaTestUnexportedEmbed struct {
+	deltaTestEmbedded
+	Name string
+}
+
+func TestDeltaUnexportedEmbed(t *testing.T) {
+	base := deltaTestUnexportedEmbed{deltaTestEmbedded{`db`, 5432}, `svc`}
+	target := deltaTestUnexportedEmbed{deltaTestEmbedded{`prod-db`, 5432}, `svc`}
+	delta, err := Delta(base, target)
+	if err != nil {
+		t.Fatal(`error running Delta: ` + err.Error())
+	}
+	want := deltaTestUnexportedEmbed{deltaTestEmbedded: deltaTestEmbedded{Host: `prod-db`}}
+	if !reflect.DeepEqual(delta, want) {
+		spew.Dump(delta)
+		t.Fatal(`Delta did not produce the expected sparse value`)
+	}
+	if err = Merge(&base, delta, WithOverride); err != nil {
+		t.Fatal(`error running Merge: ` + err.Error())
+	}
+	if !reflect.DeepEqual(base, target) {
+		spew.Dump(base)
+		t.Fatal(`merging the delta did not produce the target`)
+	}
+}
+
+func TestDeltaNilPointer(t *testing.T) {
+	if _, err := Delta((*deltaTestConfig)(nil), &deltaTestConfig{}); err != ErrNilArguments {
+		t.Fatalf(`expected %v, got %v`, ErrNilArguments, err)
+	}
+	if _, err := Delta(deltaTestConfig{}, (*deltaTestConfig)(nil)); err != ErrNilArguments {
+		t.Fatalf(`expected %v, got %v`, ErrNilArguments, err)
+	}
+}
+
+type deltaTestHidden struct {
+	x int
+}
+
+type deltaTestHiddenEmbed struct {
+	deltaTestHidden
+	A int
+}
+
+func TestDeltaHiddenEmbed(t *testing.T) {
+	base := deltaTestHiddenEmbed{deltaTestHidden{1}, 1}
+	target := deltaTestHiddenEmbed{deltaTestHidden{2}, 2}
+	delta, err := Delta(base, target)
+	if err != nil {
+		t.Fatal(`error running Delta: ` + err.Error())
+	}
+	if want := (deltaTestHiddenEmbed{A: 2}); !reflect.DeepEqual(delta, want) {
+		spew.Dump(delta)
+		t.Fatal(`Delta did not produce the expected sparse value`)
+	}
+}
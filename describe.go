@@ -0,0 +1,201 @@
+package mergo
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// FieldTagDescription is the struct tag holding the description Describe reports for a field.
+const FieldTagDescription string = `description`
+
+// FieldDoc documents a configurable field, as listed by Describe.
+type FieldDoc struct {
+	// Path is the dotted Go field path, where embedded structs add nothing, as in Diff.
+	Path         string
+	Type         string
+	Final        bool
+	Optional     bool
+	MustOverride bool
+	Secret       bool
+	// Env lists the environment variables overriding the field, in the order they are
+	// looked up; it is empty for fields that cannot come from the environment.
+	Env         []string
+	Default     string
+	Description string
+}
+
+// Describe lists the leaf fields of the struct v, or v points to, the way Merge walks
+// them: nested structs and pointers to structs are described field by field, while
+// slices, maps, Opt and structs without exported fields such as time.Time are leaves.
+// The fields of a final struct are final too.
+// Environment variable names come from the GetEnvironmentSetting method of the struct
+// holding the field if it is Overridable, or else from DefaultEnvironmentSettingPrefix,
+// followed by their upper case form, and are only given for the fields of the types
+// environment overrides support.
+func Describe(v interface{}) []FieldDoc {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			rv = reflect.New(rv.Type().Elem())
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil
+	}
+	var docs []FieldDoc
+	describeStruct(rv, ``, false, map[reflect.Type]bool{}, &docs)
+	return docs
+}
+
+// describeStruct appends the docs of the fields of v to docs; final reports that v is held
+// by a final field, whose fields Merge never overrides either.
+func describeStruct(v reflect.Value, path string, final bool, walking map[reflect.Type]bool, docs *[]FieldDoc) {
+	if walking[v.Type()] {
+		return
+	}
+	walking[v.Type()] = true
+	defer delete(walking, v.Type())
+
+	covr, overridable := v.Interface().(Overridable)
+	for i, n := 0, v.NumField(); i < n; i++ {
+		sf := v.Type().Field(i)
+		if !isExportedComponent(&sf) {
+			continue
+		}
+		fv := v.Field(i)
+		fp := path
+		if !sf.Anonymous {
+			fp = joinFieldPath(path, sf.Name)
+		}
+		fi := parseField(sf)
+		if nested, ok := describedStruct(fv); ok {
+			describeStruct(nested, fp, final || fi.Final, walking, docs)
+			continue
+		}
+		doc := FieldDoc{
+			Path:         fp,
+			Type:         sf.Type.String(),
+			Final:        final || fi.Final,
+			Optional:     fi.Optional,
+			MustOverride: fi.Mustoverride,
+			Secret:       fi.Secret,
+			Default:      fi.Default,
+			Description:  sf.Tag.Get(FieldTagDescription),
+		}
+		if !doc.Final && !fi.Complex && isEnvironmentType(sf.Type) {
+			name := DefaultEnvironmentSettingPrefix + sf.Name
+			if overridable {
+				name = covr.GetEnvironmentSetting(sf.Name)
+			}
+			doc.Env = []string{name}
+			if upper := strings.ToUpper(name); upper != name {
+				doc.Env = append(doc.Env, upper)
+			}
+		}
+		*docs = append(*docs, doc)
+	}
+}
+
+// describedStruct returns the struct Describe walks into for the field value v, if any,
+// using a zero value for a nil pointer.
+func describedStruct(v reflect.Value) (reflect.Value, bool) {
+	if v.Kind() == reflect.Ptr && v.Type().Elem().Kind() == reflect.Struct {
+		if v.IsNil() {
+			v = reflect.New(v.Type().Elem())
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct || isOptionalType(v.Type()) || !hasMergeableFields(v) {
+		return v, false
+	}
+	return v, true
+}
+
+// isEnvironmentType reports whether valueFromEnvironment can set a field of typ.
+func isEnvironmentType(typ reflect.Type) bool {
+	if isOptionalType(typ) {
+		return isParseableType(leafType(typ))
+	}
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	switch typ.Kind() {
+	case reflect.Bool, reflect.Int, reflect.Float64, reflect.String:
+		return true
+	}
+	return false
+}
+
+// RenderMarkdown renders docs as a Markdown table.
+func RenderMarkdown(docs []FieldDoc) string {
+	var b strings.Builder
+	b.WriteString("| Field | Type | Environment | Default | Tags | Description |\n")
+	b.WriteString("|---|---|---|---|---|---|\n")
+	for _, doc := range docs {
+		env := make([]string, len(doc.Env))
+		for i, name := range doc.Env {
+			env[i] = "`" + name + "`"
+		}
+		def := ``
+		if doc.Default != `` {
+			def = "`" + doc.Default + "`"
+		}
+		fmt.Fprintf(&b, "| `%s` | `%s` | %s | %s | %s | %s |\n",
+			doc.Path, doc.Type, strings.Join(env, `, `), markdownCell(def),
+			strings.Join(doc.tags(), `, `), markdownCell(doc.Description))
+	}
+	return b.String()
+}
+
+// RenderEnvTemplate renders docs as a .env file template, with a variable for each
+// field that can come from the environment, set to its default, and comments describing
+// it. Secret fields are left empty.
+func RenderEnvTemplate(docs []FieldDoc) string {
+	var b strings.Builder
+	for _, doc := range docs {
+		if len(doc.Env) == 0 {
+			continue
+		}
+		if b.Len() > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "# %s (%s)", doc.Path, doc.Type)
+		if tags := doc.tags(); len(tags) > 0 {
+			fmt.Fprintf(&b, " [%s]", strings.Join(tags, `, `))
+		}
+		b.WriteString("\n")
+		if doc.Description != `` {
+			fmt.Fprintf(&b, "# %s\n", doc.Description)
+		}
+		value := doc.Default
+		if doc.Secret {
+			value = ``
+		}
+		fmt.Fprintf(&b, "%s=%s\n", doc.Env[0], value)
+	}
+	return b.String()
+}
+
+func (doc FieldDoc) tags() []string {
+	var tags []string
+	for _, tag := range []struct {
+		set  bool
+		name string
+	}{
+		{doc.Final, FieldTagFinal},
+		{doc.Optional, FieldTagOptional},
+		{doc.MustOverride, FieldTagMustOverride},
+		{doc.Secret, FieldTagSecret},
+	} {
+		if tag.set {
+			tags = append(tags, tag.name)
+		}
+	}
+	return tags
+}
+
+func markdownCell(s string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(s, `|`, `\|`), "\n", ` `)
+}
@@ -0,0 +1,90 @@
+package mergo
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/davecgh/go-spew/spew"
+)
+
+type describeTestConfig struct {
+	Name     string `config:"final"`
+	Timeout  int    `default:"30" description:"how long to wait | at most"`
+	Interval time.Duration
+	Password string   `config:"secret,mustoverride"`
+	Retries  Opt[int] `default:"3"`
+	Hosts    []string `config:"optional"`
+	Database *describeTestDatabase
+	Created  time.Time
+}
+
+type describeTestDatabase struct {
+	Port int `default:"5432" description:"the port to connect to"`
+	Next *describeTestDatabase
+}
+
+func TestDescribe(t *testing.T) {
+	docs := Describe(&describeTestConfig{})
+	want := []FieldDoc{
+		{Path: `Name`, Type: `string`, Final: true},
+		{Path: `Timeout`, Type: `int`, Env: []string{`MSVC_Timeout`, `MSVC_TIMEOUT`}, Default: `30`, Description: `how long to wait | at most`},
+		{Path: `Interval`, Type: `time.Duration`},
+		{Path: `Password`, Type: `string`, MustOverride: true, Secret: true, Env: []string{`MSVC_Password`, `MSVC_PASSWORD`}},
+		{Path: `Retries`, Type: `mergo.Opt[int]`, Env: []string{`MSVC_Retries`, `MSVC_RETRIES`}, Default: `3`},
+		{Path: `Hosts`, Type: `[]string`, Optional: true},
+		{Path: `Database.Port`, Type: `int`, Env: []string{`MSVC_Port`, `MSVC_PORT`}, Default: `5432`, Description: `the port to connect to`},
+		{Path: `Created`, Type: `time.Time`},
+	}
+	if !reflect.DeepEqual(docs, want) {
+		spew.Dump(docs)
+		t.Fatal(`Describe did not list the expected fields`)
+	}
+
+	docs = Describe(OvrTestConfig{})
+	if len(docs) != 4 || !reflect.DeepEqual(docs[0].Env, []string{`OTC_Name`, `OTC_NAME`}) ||
+		!reflect.DeepEqual(docs[3].Env, []string{`OVRTSC_Value`, `OVRTSC_VALUE`}) {
+		spew.Dump(docs)
+		t.Fatal(`Describe did not name environment variables after an Overridable struct`)
+	}
+	type finalDatabase struct {
+		Name string
+		DB   describeTestDatabase `config:"final"`
+	}
+	docs = Describe(finalDatabase{})
+	want = []FieldDoc{
+		{Path: `Name`, Type: `string`, Env: []string{`MSVC_Name`, `MSVC_NAME`}},
+		{Path: `DB.Port`, Type: `int`, Final: true, Default: `5432`, Description: `the port to connect to`},
+	}
+	if !reflect.DeepEqual(docs, want) {
+		spew.Dump(docs)
+		t.Fatal(`expected the fields of a final struct to be final, without environment variables`)
+	}
+	if Describe(`not a struct`) != nil {
+		t.Fatal(`expected no fields for a non-struct value`)
+	}
+}
+
+func TestRenderDocs(t *testing.T) {
+	docs := append(Describe(describeTestConfig{})[:2], Describe(describeTestConfig{})[3])
+	markdown := RenderMarkdown(docs)
+	wantMarkdown := "| Field | Type | Environment | Default | Tags | Description |\n" +
+		"|---|---|---|---|---|---|\n" +
+		"| `Name` | `string` |  |  | final |  |\n" +
+		"| `Timeout` | `int` | `MSVC_Timeout`, `MSVC_TIMEOUT` | `30` |  | how long to wait \\| at most |\n" +
+		"| `Password` | `string` | `MSVC_Password`, `MSVC_PASSWORD` |  | mustoverride, secret |  |\n"
+	if markdown != wantMarkdown {
+		t.Fatal("unexpected Markdown:\n" + markdown)
+	}
+
+	env := RenderEnvTemplate(docs)
+	wantEnv := "# Timeout (int)\n" +
+		"# how long to wait | at most\n" +
+		"MSVC_Timeout=30\n" +
+		"\n" +
+		"# Password (string) [mustoverride, secret]\n" +
+		"MSVC_Password=\n"
+	if env != wantEnv {
+		t.Fatal("unexpected .env template:\n" + env)
+	}
+}
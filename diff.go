@@ -0,0 +1,263 @@
+package mergo
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// ChangeType tells how a value differs between the two sides of a Diff.
+type ChangeType string
+
+const (
+	ChangeAdded    ChangeType = `added`
+	ChangeRemoved  ChangeType = `removed`
+	ChangeModified ChangeType = `modified`
+)
+
+// RedactedValue replaces the old and new values of fields tagged `config:"secret"` in a Change,
+// also when they are part of a struct, map or slice added or removed as a whole.
+const RedactedValue string = `[REDACTED]`
+
+// Change is a single difference found by Diff.
+// Path names struct fields with dots and map keys and slice indexes with brackets,
+// e.g. Servers[0].Host or Labels[team]. Fields of embedded structs are promoted,
+// so they appear without the embedded type name.
+type Change struct {
+	Type ChangeType
+	Path string
+	From interface{}
+	To   interface{}
+}
+
+func (c Change) String() string {
+	switch c.Type {
+	case ChangeAdded:
+		return fmt.Sprintf("%s %s: %v", c.Type, c.Path, c.To)
+	case ChangeRemoved:
+		return fmt.Sprintf("%s %s: %v", c.Type, c.Path, c.From)
+	}
+	return fmt.Sprintf("%s %s: %v -> %v", c.Type, c.Path, c.From, c.To)
+}
+
+// Diff walks a and b the way Merge does (exported fields only, embedded structs,
+// maps, slices and pointers) and returns what has to change to turn a into b.
+// a and b must be of the same type; pointers are dereferenced once. When only one of
+// them is a nil pointer, the whole value is reported as added or removed, with an empty Path.
+// Structs without exported fields, such as time.Time, are compared as a whole.
+func Diff(a, b interface{}) ([]Change, error) {
+	if a == nil || b == nil {
+		return nil, ErrNilArguments
+	}
+	va, vb := reflect.ValueOf(a), reflect.ValueOf(b)
+	ta, tb := va.Type(), vb.Type()
+	if ta.Kind() == reflect.Ptr {
+		ta, va = ta.Elem(), va.Elem()
+	}
+	if tb.Kind() == reflect.Ptr {
+		tb, vb = tb.Elem(), vb.Elem()
+	}
+	if ta != tb {
+		return nil, ErrDifferentArgumentsTypes
+	}
+	d := &differ{visited: map[[2]uintptr]bool{}}
+	switch {
+	case !va.IsValid() && !vb.IsValid():
+	case !va.IsValid():
+		// a nil pointer on one side makes the whole value added or removed
+		d.record(ChangeAdded, ``, va, vb, false)
+	case !vb.IsValid():
+		d.record(ChangeRemoved, ``, va, vb, false)
+	default:
+		d.diff(va, vb, ``, false)
+	}
+	return d.changes, nil
+}
+
+type differ struct {
+	changes []Change
+	visited map[[2]uintptr]bool
+}
+
+func (d *differ) record(typ ChangeType, path string, from, to reflect.Value, secret bool) {
+	c := Change{Type: typ, Path: path}
+	if from.IsValid() {
+		c.From = redactedInterface(from, secret)
+	}
+	if to.IsValid() {
+		c.To = redactedInterface(to, secret)
+	}
+	d.changes = append(d.changes, c)
+}
+
+// redactedInterface returns v for a Change, or RedactedValue if it is secret. Whole
+// structs, maps and slices, as recorded when they are added or removed, are copied
+// with the secret fields inside them redacted.
+func redactedInterface(v reflect.Value, secret bool) interface{} {
+	if secret {
+		return RedactedValue
+	}
+	switch v.Kind() {
+	case reflect.Struct, reflect.Ptr, reflect.Map, reflect.Slice, reflect.Array, reflect.Interface:
+		c := reflect.New(v.Type()).Elem()
+		c.Set(deepCopyValue(v, map[cloneKey]reflect.Value{}))
+		redactSecrets(c)
+		return c.Interface()
+	}
+	return v.Interface()
+}
+
+// redactSecrets sets the secret fields inside the settable v to RedactedValue, or to
+// their zero value when they cannot hold a string.
+func redactSecrets(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Struct:
+		for i, n := 0, v.NumField(); i < n; i++ {
+			f := v.Field(i)
+			if !f.CanSet() {
+				continue
+			}
+			if sf := v.Type().Field(i); !sf.Anonymous && parseField(sf).Secret {
+				if f.Kind() == reflect.String {
+					f.SetString(RedactedValue)
+				} else {
+					f.Set(reflect.Zero(f.Type()))
+				}
+				continue
+			}
+			redactSecrets(f)
+		}
+	case reflect.Ptr:
+		if !v.IsNil() {
+			redactSecrets(v.Elem())
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			redactSecrets(v.Index(i))
+		}
+	case reflect.Map, reflect.Interface:
+		if v.IsNil() {
+			return
+		}
+		if v.Kind() == reflect.Interface {
+			e := reflect.New(v.Elem().Type()).Elem()
+			e.Set(v.Elem())
+			redactSecrets(e)
+			v.Set(e)
+			return
+		}
+		for _, k := range v.MapKeys() {
+			e := reflect.New(v.Type().Elem()).Elem()
+			e.Set(v.MapIndex(k))
+			redactSecrets(e)
+			v.SetMapIndex(k, e)
+		}
+	}
+}
+
+func (d *differ) diff(a, b reflect.Value, path string, secret bool) {
+	switch a.Kind() {
+	case reflect.Struct:
+		if !hasMergeableFields(a) {
+			break
+		}
+		for i, n := 0, a.NumField(); i < n; i++ {
+			sf := a.Type().Field(i)
+			if sf.Anonymous && sf.Type.Kind() == reflect.Struct {
+				d.diff(a.Field(i), b.Field(i), path, secret)
+			} else if isExportedComponent(&sf) {
+				fi := parseField(sf)
+				d.diff(a.Field(i), b.Field(i), joinFieldPath(path, sf.Name), secret || fi.Secret)
+			}
+		}
+		return
+	case reflect.Map:
+		for _, key := range unionMapKeys(a, b) {
+			ae, be := a.MapIndex(key), b.MapIndex(key)
+			kp := joinIndexPath(path, key.Interface())
+			switch {
+			case !ae.IsValid():
+				d.record(ChangeAdded, kp, reflect.Value{}, be, secret)
+			case !be.IsValid():
+				d.record(ChangeRemoved, kp, ae, reflect.Value{}, secret)
+			default:
+				d.diff(ae, be, kp, secret)
+			}
+		}
+		return
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < a.Len() || i < b.Len(); i++ {
+			ip := joinIndexPath(path, i)
+			switch {
+			case i >= a.Len():
+				d.record(ChangeAdded, ip, reflect.Value{}, b.Index(i), secret)
+			case i >= b.Len():
+				d.record(ChangeRemoved, ip, a.Index(i), reflect.Value{}, secret)
+			default:
+				d.diff(a.Index(i), b.Index(i), ip, secret)
+			}
+		}
+		return
+	case reflect.Ptr, reflect.Interface:
+		switch {
+		case a.IsNil() && b.IsNil():
+		case a.IsNil():
+			d.record(ChangeAdded, path, reflect.Value{}, b.Elem(), secret)
+		case b.IsNil():
+			d.record(ChangeRemoved, path, a.Elem(), reflect.Value{}, secret)
+		case a.Elem().Type() != b.Elem().Type():
+			d.record(ChangeModified, path, a.Elem(), b.Elem(), secret)
+		case a.Kind() == reflect.Ptr:
+			seen := [2]uintptr{a.Pointer(), b.Pointer()}
+			if seen[0] == seen[1] || d.visited[seen] {
+				return
+			}
+			d.visited[seen] = true
+			d.diff(a.Elem(), b.Elem(), path, secret)
+		default:
+			d.diff(a.Elem(), b.Elem(), path, secret)
+		}
+		return
+	case reflect.Func, reflect.Chan, reflect.UnsafePointer:
+		if a.IsNil() != b.IsNil() {
+			d.record(ChangeModified, path, a, b, secret)
+		}
+		return
+	}
+	if !a.CanInterface() {
+		// an embedded struct of an unexported type without exported fields
+		return
+	}
+	if !reflect.DeepEqual(a.Interface(), b.Interface()) {
+		d.record(ChangeModified, path, a, b, secret)
+	}
+}
+
+// unionMapKeys returns the keys present in any of the maps, in a stable order.
+func unionMapKeys(maps ...reflect.Value) []reflect.Value {
+	seen := map[interface{}]bool{}
+	var keys []reflect.Value
+	for _, m := range maps {
+		for _, k := range m.MapKeys() {
+			if !seen[k.Interface()] {
+				seen[k.Interface()] = true
+				keys = append(keys, k)
+			}
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return fmt.Sprint(keys[i].Interface()) < fmt.Sprint(keys[j].Interface())
+	})
+	return keys
+}
+
+func joinFieldPath(parent, name string) string {
+	if parent == `` {
+		return name
+	}
+	return parent + `.` + name
+}
+
+func joinIndexPath(parent string, key interface{}) string {
+	return fmt.Sprintf("%s[%v]", parent, key)
+}
@@ -0,0 +1,155 @@
+package mergo
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/davecgh/go-spew/spew"
+)
+
+type diffTestDatabase struct {
+	Host     string
+	Password string `config:"secret"`
+}
+
+type diffTestConfig struct {
+	RequiredConfig
+	Timeout  time.Duration
+	Started  time.Time
+	Database *diffTestDatabase
+	Labels   map[string]string
+	Hosts    []string
+	Any      interface{}
+	hidden   string
+}
+
+func TestDiff(t *testing.T) {
+	now := time.Now()
+	a := diffTestConfig{
+		RequiredConfig: RequiredConfig{Environment: `dev`, LogLevel: `info`},
+		Timeout:        time.Second,
+		Database:       &diffTestDatabase{`db1`, `hunter2`},
+		Labels:         map[string]string{`team`: `core`, `tier`: `web`},
+		Hosts:          []string{`a`, `b`},
+		Any:            1,
+		hidden:         `x`,
+	}
+	b := a
+	b.LogLevel = `debug`
+	b.Started = now
+	b.Database = &diffTestDatabase{`db1`, `correct horse`}
+	b.Labels = map[string]string{`team`: `core`, `owner`: `ops`}
+	b.Hosts = []string{`a`}
+	b.Any = `one`
+	b.hidden = `y`
+
+	changes, err := Diff(a, &b)
+	if err != nil {
+		t.Fatal(`error running Diff: ` + err.Error())
+	}
+	want := []Change{
+		{ChangeModified, `LogLevel`, `info`, `debug`},
+		{ChangeModified, `Started`, time.Time{}, now},
+		{ChangeModified, `Database.Password`, RedactedValue, RedactedValue},
+		{ChangeAdded, `Labels[owner]`, nil, `ops`},
+		{ChangeRemoved, `Labels[tier]`, `web`, nil},
+		{ChangeRemoved, `Hosts[1]`, `b`, nil},
+		{ChangeModified, `Any`, 1, `one`},
+	}
+	if !reflect.DeepEqual(changes, want) {
+		spew.Dump(changes)
+		t.Fatal(`Diff did not produce expected changes`)
+	}
+
+	if changes, err = Diff(a, a); err != nil || len(changes) != 0 {
+		spew.Dump(changes)
+		t.Fatal(`Diff of a value with itself should be empty`)
+	}
+}
+
+func TestDiffPointers(t *testing.T) {
+	a := diffTestConfig{}
+	b := diffTestConfig{Database: &diffTestDatabase{Host: `db`}}
+	changes, err := Diff(&a, &b)
+	if err != nil {
+		t.Fatal(`error running Diff: ` + err.Error())
+	}
+	if len(changes) != 1 || changes[0].Type != ChangeAdded || changes[0].Path != `Database` {
+		spew.Dump(changes)
+		t.Fatal(`expected the new pointer to be reported as added`)
+	}
+}
+
+func TestDiffRedactsAddedValues(t *testing.T) {
+	a := diffTestConfig{}
+	b := diffTestConfig{
+		Database: &diffTestDatabase{`h`, `hunter2`},
+		Any:      []diffTestDatabase{{`h`, `hunter2`}},
+	}
+	changes, err := Diff(a, b)
+	if err != nil {
+		t.Fatal(`error running Diff: ` + err.Error())
+	}
+	want := []Change{
+		{ChangeAdded, `Database`, nil, diffTestDatabase{`h`, RedactedValue}},
+		{ChangeAdded, `Any`, nil, []diffTestDatabase{{`h`, RedactedValue}}},
+	}
+	if !reflect.DeepEqual(changes, want) {
+		spew.Dump(changes)
+		t.Fatal(`Diff did not redact the secrets of added values`)
+	}
+	if b.Database.Password != `hunter2` {
+		t.Fatal(`Diff modified its argument`)
+	}
+	if changes, err = Diff(b, a); err != nil || len(changes) != 2 || fmt.Sprint(changes[0]) != `removed Database: {h [REDACTED]}` {
+		spew.Dump(changes)
+		t.Fatal(`Diff did not redact the secrets of removed values`)
+	}
+}
+
+type diffTestHidden struct {
+	x int
+}
+
+type diffTestEmbedded struct {
+	diffTestHidden
+	A int
+}
+
+func TestDiffEmbeddedUnexportedStruct(t *testing.T) {
+	changes, err := Diff(diffTestEmbedded{diffTestHidden{1}, 1}, diffTestEmbedded{diffTestHidden{2}, 2})
+	if err != nil {
+		t.Fatal(`error running Diff: ` + err.Error())
+	}
+	if want := []Change{{ChangeModified, `A`, 1, 2}}; !reflect.DeepEqual(changes, want) {
+		spew.Dump(changes)
+		t.Fatal(`Diff did not produce expected changes`)
+	}
+}
+
+func TestDiffNilPointer(t *testing.T) {
+	b := &diffTestConfig{Hosts: []string{`a`}}
+	changes, err := Diff((*diffTestConfig)(nil), b)
+	if err != nil {
+		t.Fatal(`error running Diff: ` + err.Error())
+	}
+	if want := []Change{{ChangeAdded, ``, nil, *b}}; !reflect.DeepEqual(changes, want) {
+		spew.Dump(changes)
+		t.Fatal(`expected the whole value to be reported as added`)
+	}
+	if changes, err = Diff(b, (*diffTestConfig)(nil)); err != nil || len(changes) != 1 || changes[0].Type != ChangeRemoved {
+		spew.Dump(changes)
+		t.Fatal(`expected the whole value to be reported as removed`)
+	}
+	if changes, err = Diff((*diffTestConfig)(nil), (*diffTestConfig)(nil)); err != nil || len(changes) != 0 {
+		t.Fatal(`expected no changes between nil pointers`)
+	}
+}
+
+func TestDiffDifferentTypes(t *testing.T) {
+	if _, err := Diff(diffTestConfig{}, RequiredConfig{}); err != ErrDifferentArgumentsTypes {
+		t.Fatalf(`expected %v, got %v`, ErrDifferentArgumentsTypes, err)
+	}
+}
@@ -0,0 +1,95 @@
+package mergo
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/davecgh/go-spew/spew"
+)
+
+// emptyTestPort is empty when it is -1, so that 0 can be set on purpose.
+type emptyTestPort int
+
+func (p *emptyTestPort) IsZero() bool {
+	return *p == -1
+}
+
+type emptyTestConfig struct {
+	Started time.Time
+	Port    emptyTestPort
+	Name    string
+	Tags    map[string]string
+}
+
+func TestMergeIsZero(t *testing.T) {
+	started := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	dst := emptyTestConfig{Port: -1}
+	src := emptyTestConfig{Started: started, Port: 8080}
+	if err := Merge(&dst, src); err != nil {
+		t.Fatal(`error running Merge: ` + err.Error())
+	}
+	if !dst.Started.Equal(started) || dst.Port != 8080 {
+		spew.Dump(dst)
+		t.Fatal(`Merge did not fill the values empty by their IsZero method`)
+	}
+
+	dst = emptyTestConfig{Port: 0}
+	if err := Merge(&dst, src); err != nil {
+		t.Fatal(`error running Merge: ` + err.Error())
+	}
+	if dst.Port != 0 {
+		t.Fatalf(`expected the port 0 to be kept, got %d`, dst.Port)
+	}
+
+	dst = emptyTestConfig{Started: started, Port: 80}
+	if err := Merge(&dst, emptyTestConfig{Port: -1}, WithOverride); err != nil {
+		t.Fatal(`error running Merge: ` + err.Error())
+	}
+	if !dst.Started.Equal(started) || dst.Port != 80 {
+		spew.Dump(dst)
+		t.Fatal(`Merge WithOverride replaced values with empty ones`)
+	}
+}
+
+func TestMergeWithEmptyValueFunc(t *testing.T) {
+	unset := func(v reflect.Value) (bool, bool) {
+		if v.Kind() == reflect.String {
+			return v.String() == `` || v.String() == `unset`, true
+		}
+		return false, false
+	}
+	dst := emptyTestConfig{Port: -1, Name: `unset`}
+	src := emptyTestConfig{Port: 8080, Name: `api`}
+	if err := Merge(&dst, src, WithEmptyValueFunc(unset)); err != nil {
+		t.Fatal(`error running Merge: ` + err.Error())
+	}
+	if dst.Name != `api` || dst.Port != 8080 {
+		spew.Dump(dst)
+		t.Fatal(`Merge did not use the emptiness predicate`)
+	}
+
+	if err := Merge(&dst, emptyTestConfig{Name: `unset`}, WithOverride, WithEmptyValueFunc(unset)); err != nil {
+		t.Fatal(`error running Merge: ` + err.Error())
+	}
+	if dst.Name != `api` {
+		t.Fatalf(`expected an empty src not to override, got %q`, dst.Name)
+	}
+}
+
+func TestDeltaIsZero(t *testing.T) {
+	base := emptyTestConfig{Started: time.Now(), Name: `a`}
+	target := base
+	target.Name = `b`
+	delta, err := Delta(base, target)
+	if err != nil {
+		t.Fatal(`error running Delta: ` + err.Error())
+	}
+	if err = Merge(&base, delta, WithOverride); err != nil {
+		t.Fatal(`error running Merge: ` + err.Error())
+	}
+	if !reflect.DeepEqual(base, target) {
+		spew.Dump(base)
+		t.Fatal(`merging the delta did not yield target`)
+	}
+}
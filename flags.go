@@ -0,0 +1,223 @@
+package mergo
+
+import (
+	"flag"
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode"
+)
+
+// FlagBinding holds the flags BindFlags registered for the fields of a struct type.
+type FlagBinding struct {
+	fs     *flag.FlagSet
+	typ    reflect.Type
+	fields map[string]*flagValue
+}
+
+// BindFlags registers in fs a flag for every leaf field of the struct dst points to that
+// Merge can set: string, bool, number and time.Duration fields, pointers to and slices
+// of them, and Opt of them. Final fields are left out.
+// A flag is named after the field path in kebab case, e.g. -database.max-conns for
+// Database.MaxConns, where embedded structs add nothing, or as the `config:"flag=name"`
+// tag element says. Its help text is the `usage` tag, its default the value in dst.
+// Slice flags take comma-separated values and may be repeated.
+// After fs.Parse, Apply sets the flags that were passed.
+func BindFlags(fs *flag.FlagSet, dst interface{}) (*FlagBinding, error) {
+	if dst == nil {
+		return nil, ErrNilArguments
+	}
+	vDst := reflect.ValueOf(dst)
+	if vDst.Kind() != reflect.Ptr || vDst.IsNil() {
+		return nil, ErrNonPointerAgument
+	}
+	if vDst.Elem().Kind() != reflect.Struct {
+		return nil, ErrExpectedStructAsDestination
+	}
+	b := &FlagBinding{fs: fs, typ: vDst.Elem().Type(), fields: map[string]*flagValue{}}
+	if err := b.bind(vDst.Elem(), nil, ``); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// Apply sets in dst, a pointer to the struct type given to BindFlags, the fields whose
+// flags were passed to fs.Parse, overriding what dst holds, and allocating pointers as
+// needed. Flags are meant to be the last layer, applied once the others are merged.
+func (b *FlagBinding) Apply(dst interface{}) error {
+	if dst == nil {
+		return ErrNilArguments
+	}
+	vDst := reflect.ValueOf(dst)
+	if vDst.Kind() != reflect.Ptr || vDst.IsNil() {
+		return ErrNonPointerAgument
+	}
+	if vDst.Elem().Type() != b.typ {
+		return ErrDifferentArgumentsTypes
+	}
+	b.fs.Visit(func(f *flag.Flag) {
+		if fv, ok := b.fields[f.Name]; ok && fv.value.IsValid() {
+			fieldByIndexAlloc(vDst.Elem(), fv.index).Set(fv.value)
+		}
+	})
+	return nil
+}
+
+// bind registers the flags of the struct fields of v, a zero value when it stands for a
+// nil pointer.
+func (b *FlagBinding) bind(v reflect.Value, index []int, prefix string) error {
+	typ := v.Type()
+	for i, n := 0, typ.NumField(); i < n; i++ {
+		sf := typ.Field(i)
+		if !isExportedComponent(&sf) {
+			continue
+		}
+		fi := parseField(sf)
+		if fi.Final {
+			continue
+		}
+		fieldIndex := append(append([]int{}, index...), i)
+		fv := v.Field(i)
+		name := fi.Flag
+		if name == `` {
+			name = joinFlagName(prefix, kebabCase(sf.Name))
+		}
+
+		if isFlagType(sf.Type) {
+			if b.fs.Lookup(name) != nil {
+				return fmt.Errorf("flag -%s of field %s is already defined", name, sf.Name)
+			}
+			value := &flagValue{typ: sf.Type, index: fieldIndex, def: formatFlagValue(fv)}
+			b.fields[name] = value
+			b.fs.Var(value, name, fi.Usage)
+			continue
+		}
+		st := sf.Type
+		if st.Kind() == reflect.Ptr {
+			st = st.Elem()
+			if fv.IsNil() {
+				fv = reflect.New(st).Elem()
+			} else {
+				fv = fv.Elem()
+			}
+		}
+		if st.Kind() == reflect.Struct && hasMergeableFields(fv) {
+			if sf.Anonymous && fi.Flag == `` {
+				// fields of embedded structs are promoted
+				name = prefix
+			}
+			if err := b.bind(fv, fieldIndex, name); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// flagValue is the flag.Value of a field, holding its parsed value once it is set.
+type flagValue struct {
+	typ   reflect.Type
+	index []int
+	def   string
+	value reflect.Value
+}
+
+func (f *flagValue) String() string {
+	if f == nil || f.typ == nil {
+		return ``
+	}
+	if f.value.IsValid() {
+		return formatFlagValue(f.value)
+	}
+	return f.def
+}
+
+func (f *flagValue) Set(s string) error {
+	v, err := parseFieldValue(s, f.typ)
+	if err != nil {
+		return err
+	}
+	if f.value.IsValid() && f.typ.Kind() == reflect.Slice {
+		v = reflect.AppendSlice(f.value, v)
+	}
+	f.value = v
+	return nil
+}
+
+// IsBoolFlag lets bool fields be passed as -name, without a value.
+func (f *flagValue) IsBoolFlag() bool {
+	return leafType(f.typ).Kind() == reflect.Bool
+}
+
+// isFlagType reports whether a field of typ can be set from a flag, by parseFieldValue.
+func isFlagType(typ reflect.Type) bool {
+	return isParseableType(leafType(typ))
+}
+
+// formatFlagValue formats v the way its flag takes it, or "" if v is empty.
+func formatFlagValue(v reflect.Value) string {
+	if opt, ok := asOptional(v); ok {
+		value, set := opt.optionalValue()
+		if !set {
+			return ``
+		}
+		return formatFlagValue(value)
+	}
+	if !v.IsValid() || isEmptyValue(v) {
+		return ``
+	}
+	switch v.Kind() {
+	case reflect.Ptr:
+		return fmt.Sprint(v.Elem().Interface())
+	case reflect.Slice:
+		parts := make([]string, v.Len())
+		for i := range parts {
+			parts[i] = fmt.Sprint(v.Index(i).Interface())
+		}
+		return strings.Join(parts, `,`)
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+// fieldByIndexAlloc returns the nested field of v at index, allocating the nil pointers
+// to structs on the way.
+func fieldByIndexAlloc(v reflect.Value, index []int) reflect.Value {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v
+}
+
+func joinFlagName(prefix, name string) string {
+	if prefix == `` {
+		return name
+	}
+	return prefix + `.` + name
+}
+
+// kebabCase turns a Go field name into a flag name, e.g. MaxConns into max-conns and
+// HTTPPort into http-port.
+func kebabCase(name string) string {
+	var b strings.Builder
+	runes := []rune(name)
+	for i, r := range runes {
+		switch {
+		case r == '_':
+			r = '-'
+		case unicode.IsUpper(r):
+			if i > 0 && runes[i-1] != '_' &&
+				(!unicode.IsUpper(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1]))) {
+				b.WriteByte('-')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,100 @@
+package mergo
+
+import (
+	"bytes"
+	"flag"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/davecgh/go-spew/spew"
+)
+
+type flagsTestConfig struct {
+	RequiredConfig
+	HTTPPort int           `usage:"port to listen on"`
+	Timeout  time.Duration `config:"flag=timeout"`
+	Debug    bool
+	Retries  Opt[int]
+	Tags     []string
+	Database *flagsTestDatabase
+	Started  time.Time
+	Secret   string `config:"final"`
+}
+
+type flagsTestDatabase struct {
+	MaxConns int
+	Host     *string
+}
+
+func TestBindFlags(t *testing.T) {
+	fs := flag.NewFlagSet(`test`, flag.ContinueOnError)
+	defaults := flagsTestConfig{HTTPPort: 8080}
+	binding, err := BindFlags(fs, &defaults)
+	if err != nil {
+		t.Fatal(`error running BindFlags: ` + err.Error())
+	}
+	var names []string
+	fs.VisitAll(func(f *flag.Flag) { names = append(names, f.Name) })
+	wantNames := []string{
+		`branch`, `build`, `commit`, `database.host`, `database.max-conns`, `date-built`, `debug`,
+		`environment`, `http-port`, `image-tag`, `log-level`, `retries`, `tags`, `timeout`, `version`,
+	}
+	if !reflect.DeepEqual(names, wantNames) {
+		spew.Dump(names)
+		t.Fatal(`BindFlags did not register the expected flags`)
+	}
+	var usage bytes.Buffer
+	fs.SetOutput(&usage)
+	fs.PrintDefaults()
+	if !strings.Contains(usage.String(), `port to listen on (default 8080)`) {
+		t.Fatal(`expected the usage tag and default in the help text: ` + usage.String())
+	}
+
+	err = fs.Parse([]string{`-debug`, `-retries=0`, `-timeout`, `5s`, `-database.host`, `db`, `-tags`, `a,b`, `-tags`, `c`, `-log-level`, `warn`})
+	if err != nil {
+		t.Fatal(`error parsing flags: ` + err.Error())
+	}
+	cfg := flagsTestConfig{
+		RequiredConfig: RequiredConfig{LogLevel: `info`, Environment: `prod`},
+		HTTPPort:       9090,
+		Retries:        Some(3),
+	}
+	if err = binding.Apply(&cfg); err != nil {
+		t.Fatal(`error running Apply: ` + err.Error())
+	}
+	host := `db`
+	want := flagsTestConfig{
+		RequiredConfig: RequiredConfig{LogLevel: `warn`, Environment: `prod`},
+		HTTPPort:       9090,
+		Timeout:        5 * time.Second,
+		Debug:          true,
+		Retries:        Some(0),
+		Tags:           []string{`a`, `b`, `c`},
+		Database:       &flagsTestDatabase{Host: &host},
+	}
+	if !reflect.DeepEqual(cfg, want) {
+		spew.Dump(cfg)
+		t.Fatal(`Apply did not set exactly the passed flags`)
+	}
+
+	if err = fs.Parse([]string{`-http-port`, `eighty`}); err == nil {
+		t.Fatal(`expected an error parsing an invalid int flag`)
+	}
+}
+
+func TestKebabCase(t *testing.T) {
+	for name, want := range map[string]string{
+		`LogLevel`:           `log-level`,
+		`HTTPPort`:           `http-port`,
+		`OverrideConfigPath`: `override-config-path`,
+		`Log_level`:          `log-level`,
+		`ID`:                 `id`,
+		`Retries2`:           `retries2`,
+	} {
+		if got := kebabCase(name); got != want {
+			t.Errorf(`kebabCase(%q) = %q, want %q`, name, got, want)
+		}
+	}
+}
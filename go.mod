@@ -1,8 +1,9 @@
 module github.com/elephant-insurance/mergo
 
-go 1.13
+go 1.18
 
 require (
+	github.com/BurntSushi/toml v1.3.2
 	github.com/davecgh/go-spew v1.1.1
 	github.com/elephant-insurance/go-microservice-arch/v2 v2.0.0
 	github.com/imdario/mergo v0.3.11
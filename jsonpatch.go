@@ -0,0 +1,530 @@
+package mergo
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Errors reported by ApplyJSONPatch. They are wrapped in a *PatchError carrying
+// the operation and the JSON Pointer that failed.
+var (
+	ErrPatchInvalidOperation = errors.New("invalid patch operation")
+	ErrPatchPathNotFound     = errors.New("path not found")
+	ErrPatchFinalField       = errors.New("field is final")
+	ErrPatchTestFailed       = errors.New("test failed")
+	ErrPatchTypeMismatch     = errors.New("value type does not match target")
+)
+
+// PatchError describes the RFC 6902 operation that could not be applied.
+type PatchError struct {
+	Op   string
+	Path string
+	Err  error
+}
+
+func (e *PatchError) Error() string {
+	return fmt.Sprintf("json patch %s %q: %v", e.Op, e.Path, e.Err)
+}
+
+func (e *PatchError) Unwrap() error {
+	return e.Err
+}
+
+type patchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from"`
+	Value json.RawMessage `json:"value"`
+}
+
+// ApplyJSONPatch applies a RFC 6902 JSON Patch document to dst, which must be a pointer.
+// JSON Pointers are resolved against struct fields by their json tag (or field name),
+// against maps by key and against slices by index. Unexported fields are never reachable,
+// and fields tagged `config:"final"` cannot be modified by any operation.
+// Operations are applied in order to a copy of dst, which replaces dst only when all of
+// them succeed; the first failure is returned as a *PatchError and leaves dst untouched.
+func ApplyJSONPatch(dst interface{}, ops []byte) error {
+	if dst == nil {
+		return ErrNilArguments
+	}
+	vDst := reflect.ValueOf(dst)
+	if vDst.Kind() != reflect.Ptr || vDst.IsNil() {
+		return ErrNonPointerAgument
+	}
+	var patch []patchOperation
+	if err := json.Unmarshal(ops, &patch); err != nil {
+		return err
+	}
+	doc := reflect.New(vDst.Elem().Type()).Elem()
+	doc.Set(deepCopyValue(vDst.Elem(), map[cloneKey]reflect.Value{}))
+	for _, op := range patch {
+		if err := applyPatchOperation(doc, op); err != nil {
+			return &PatchError{Op: op.Op, Path: op.Path, Err: err}
+		}
+	}
+	vDst.Elem().Set(doc)
+	return nil
+}
+
+func applyPatchOperation(root reflect.Value, op patchOperation) error {
+	tokens, err := parseJSONPointer(op.Path)
+	if err != nil {
+		return err
+	}
+	switch op.Op {
+	case "add":
+		return patchAt(root, tokens, true, func(c reflect.Value, tok string) error {
+			v, err := decodePatchValue(op.Value, patchElemType(c, tok))
+			if err != nil {
+				return err
+			}
+			return patchAdd(c, tok, v)
+		})
+	case "replace":
+		return patchAt(root, tokens, true, func(c reflect.Value, tok string) error {
+			if _, err := patchGet(c, tok); err != nil {
+				return err
+			}
+			v, err := decodePatchValue(op.Value, patchElemType(c, tok))
+			if err != nil {
+				return err
+			}
+			return patchSet(c, tok, v)
+		})
+	case "remove":
+		if len(tokens) == 0 {
+			return fmt.Errorf("%w: cannot remove the whole document", ErrPatchInvalidOperation)
+		}
+		return patchAt(root, tokens, true, patchRemove)
+	case "test":
+		return patchAt(root, tokens, false, func(c reflect.Value, tok string) error {
+			cur, err := patchGet(c, tok)
+			if err != nil {
+				return err
+			}
+			v, err := decodePatchValue(op.Value, cur.Type())
+			if err != nil {
+				return err
+			}
+			if !patchValuesEqual(cur, v) {
+				return ErrPatchTestFailed
+			}
+			return nil
+		})
+	case "move", "copy":
+		from, err := parseJSONPointer(op.From)
+		if err != nil {
+			return err
+		}
+		if op.Op == "move" && strings.HasPrefix(op.Path+"/", op.From+"/") && op.Path != op.From {
+			return fmt.Errorf("%w: cannot move %q into one of its children", ErrPatchInvalidOperation, op.From)
+		}
+		var v reflect.Value
+		if err = patchAt(root, from, false, func(c reflect.Value, tok string) error {
+			cur, err := patchGet(c, tok)
+			if err != nil {
+				return err
+			}
+			v = reflect.New(cur.Type()).Elem()
+			v.Set(cur)
+			return nil
+		}); err != nil {
+			return err
+		}
+		if op.Op == "move" {
+			if err = patchAt(root, from, true, patchRemove); err != nil {
+				return err
+			}
+		}
+		return patchAt(root, tokens, true, func(c reflect.Value, tok string) error {
+			return patchAdd(c, tok, v)
+		})
+	}
+	return fmt.Errorf("%w: %q", ErrPatchInvalidOperation, op.Op)
+}
+
+// parseJSONPointer splits a RFC 6901 pointer into its unescaped reference tokens.
+func parseJSONPointer(p string) ([]string, error) {
+	if p == "" {
+		return nil, nil
+	}
+	if p[0] != '/' {
+		return nil, fmt.Errorf("%w: pointer %q must start with /", ErrPatchInvalidOperation, p)
+	}
+	tokens := strings.Split(p[1:], "/")
+	for i, t := range tokens {
+		tokens[i] = strings.Replace(strings.Replace(t, "~1", "/", -1), "~0", "~", -1)
+	}
+	return tokens, nil
+}
+
+// patchAt walks v along tokens and calls fn with the container holding the last token.
+// Map elements and interface contents are not addressable, so they are copied,
+// patched and stored back on the way out. When mutate is set, final fields on the
+// way are refused.
+func patchAt(v reflect.Value, tokens []string, mutate bool, fn func(container reflect.Value, token string) error) error {
+	if len(tokens) == 0 {
+		return patchRoot(v, fn)
+	}
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return ErrPatchPathNotFound
+		}
+		return patchAt(v.Elem(), tokens, mutate, fn)
+	case reflect.Interface:
+		if v.IsNil() {
+			return ErrPatchPathNotFound
+		}
+		cp := reflect.New(v.Elem().Type()).Elem()
+		cp.Set(v.Elem())
+		if err := patchAt(cp, tokens, mutate, fn); err != nil {
+			return err
+		}
+		v.Set(cp)
+		return nil
+	}
+	if len(tokens) == 1 {
+		return fn(v, tokens[0])
+	}
+	switch v.Kind() {
+	case reflect.Map:
+		key, err := patchMapKey(v.Type(), tokens[0])
+		if err != nil {
+			return err
+		}
+		elem := v.MapIndex(key)
+		if !elem.IsValid() {
+			return ErrPatchPathNotFound
+		}
+		cp := reflect.New(elem.Type()).Elem()
+		cp.Set(elem)
+		if err = patchAt(cp, tokens[1:], mutate, fn); err != nil {
+			return err
+		}
+		v.SetMapIndex(key, cp)
+		return nil
+	case reflect.Struct:
+		f, fi, ok := patchStructField(v, tokens[0])
+		if !ok {
+			return ErrPatchPathNotFound
+		}
+		if mutate && fi.Final {
+			return ErrPatchFinalField
+		}
+		return patchAt(f, tokens[1:], mutate, fn)
+	}
+	next, err := patchGet(v, tokens[0])
+	if err != nil {
+		return err
+	}
+	return patchAt(next, tokens[1:], mutate, fn)
+}
+
+// patchRoot handles operations whose path is the whole document ("") by
+// addressing it as the only element of an array.
+func patchRoot(v reflect.Value, fn func(container reflect.Value, token string) error) error {
+	holder := reflect.New(reflect.ArrayOf(1, v.Type())).Elem()
+	holder.Index(0).Set(v)
+	if err := fn(holder, "0"); err != nil {
+		return err
+	}
+	v.Set(holder.Index(0))
+	return nil
+}
+
+// patchStructField finds the exported field of v addressed by name, looking through
+// embedded structs the way encoding/json does. Names match exactly, as RFC 6901 requires.
+func patchStructField(v reflect.Value, name string) (reflect.Value, FieldInfo, bool) {
+	for i, n := 0, v.NumField(); i < n; i++ {
+		sf := v.Type().Field(i)
+		if !isExportedComponent(&sf) && !sf.Anonymous {
+			continue
+		}
+		key, _, skip := tagFieldName(sf, "json")
+		if skip {
+			continue
+		}
+		if sf.Anonymous && v.Field(i).Kind() == reflect.Struct && key == sf.Name {
+			if f, fi, ok := patchStructField(v.Field(i), name); ok {
+				return f, fi, ok
+			}
+			continue
+		}
+		if !isExportedComponent(&sf) {
+			continue
+		}
+		if key == name {
+			return v.Field(i), parseField(sf), true
+		}
+	}
+	return reflect.Value{}, FieldInfo{}, false
+}
+
+func patchMapKey(typ reflect.Type, tok string) (reflect.Value, error) {
+	if typ.Key().Kind() != reflect.String {
+		return reflect.Value{}, fmt.Errorf("%w: map key type %s is not supported", ErrPatchTypeMismatch, typ.Key())
+	}
+	return reflect.ValueOf(tok).Convert(typ.Key()), nil
+}
+
+func patchIndex(v reflect.Value, tok string, allowEnd bool) (int, error) {
+	if allowEnd && tok == "-" {
+		return v.Len(), nil
+	}
+	i, err := strconv.Atoi(tok)
+	if err != nil || i < 0 || (tok != "0" && strings.HasPrefix(tok, "0")) {
+		return 0, fmt.Errorf("%w: invalid index %q", ErrPatchPathNotFound, tok)
+	}
+	max := v.Len() - 1
+	if allowEnd {
+		max = v.Len()
+	}
+	if i > max {
+		return 0, fmt.Errorf("%w: index %d out of range", ErrPatchPathNotFound, i)
+	}
+	return i, nil
+}
+
+// patchElemType is the type a value stored under tok in container must have.
+func patchElemType(c reflect.Value, tok string) reflect.Type {
+	switch c.Kind() {
+	case reflect.Struct:
+		if f, _, ok := patchStructField(c, tok); ok {
+			return f.Type()
+		}
+	case reflect.Map, reflect.Slice, reflect.Array:
+		return c.Type().Elem()
+	}
+	return nil
+}
+
+func decodePatchValue(raw json.RawMessage, typ reflect.Type) (reflect.Value, error) {
+	if typ == nil {
+		return reflect.Value{}, ErrPatchPathNotFound
+	}
+	if len(raw) == 0 {
+		return reflect.Value{}, fmt.Errorf("%w: missing value", ErrPatchInvalidOperation)
+	}
+	v := reflect.New(typ)
+	if err := json.Unmarshal(raw, v.Interface()); err != nil {
+		return reflect.Value{}, fmt.Errorf("%w: %v", ErrPatchTypeMismatch, err)
+	}
+	return v.Elem(), nil
+}
+
+// patchValuesEqual compares a and b as JSON values, so numbers compare by value
+// whatever their Go type, e.g. an int and a float64 in an interface{}. Values that
+// cannot be marshaled, such as maps with interface{} keys, are compared as they are.
+func patchValuesEqual(a, b reflect.Value) bool {
+	var ja, jb interface{}
+	if err := normalizeJSONValue(a.Interface(), &ja); err != nil {
+		return reflect.DeepEqual(a.Interface(), b.Interface())
+	}
+	if err := normalizeJSONValue(b.Interface(), &jb); err != nil {
+		return reflect.DeepEqual(a.Interface(), b.Interface())
+	}
+	return reflect.DeepEqual(ja, jb)
+}
+
+func normalizeJSONValue(v interface{}, out *interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, out)
+}
+
+func patchGet(c reflect.Value, tok string) (reflect.Value, error) {
+	switch c.Kind() {
+	case reflect.Struct:
+		if f, _, ok := patchStructField(c, tok); ok {
+			return f, nil
+		}
+	case reflect.Map:
+		key, err := patchMapKey(c.Type(), tok)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		if e := c.MapIndex(key); e.IsValid() {
+			return e, nil
+		}
+	case reflect.Slice, reflect.Array:
+		i, err := patchIndex(c, tok, false)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		return c.Index(i), nil
+	case reflect.Ptr, reflect.Interface:
+		if !c.IsNil() {
+			return patchGet(c.Elem(), tok)
+		}
+	}
+	return reflect.Value{}, ErrPatchPathNotFound
+}
+
+func patchAssignable(v reflect.Value, typ reflect.Type) (reflect.Value, error) {
+	if !v.Type().AssignableTo(typ) {
+		return reflect.Value{}, fmt.Errorf("%w: cannot use %s as %s", ErrPatchTypeMismatch, v.Type(), typ)
+	}
+	return v, nil
+}
+
+// patchSet replaces the existing value under tok.
+func patchSet(c reflect.Value, tok string, v reflect.Value) error {
+	switch c.Kind() {
+	case reflect.Struct:
+		f, fi, ok := patchStructField(c, tok)
+		if !ok {
+			return ErrPatchPathNotFound
+		}
+		if fi.Final {
+			return ErrPatchFinalField
+		}
+		if _, err := patchAssignable(v, f.Type()); err != nil {
+			return err
+		}
+		if patchFinalChanged(f, v) {
+			return ErrPatchFinalField
+		}
+		f.Set(v)
+		return nil
+	case reflect.Map:
+		key, err := patchMapKey(c.Type(), tok)
+		if err != nil {
+			return err
+		}
+		if _, err = patchAssignable(v, c.Type().Elem()); err != nil {
+			return err
+		}
+		if old := c.MapIndex(key); old.IsValid() && patchFinalChanged(old, v) {
+			return ErrPatchFinalField
+		}
+		if c.IsNil() {
+			c.Set(reflect.MakeMap(c.Type()))
+		}
+		c.SetMapIndex(key, v)
+		return nil
+	case reflect.Slice, reflect.Array:
+		i, err := patchIndex(c, tok, false)
+		if err != nil {
+			return err
+		}
+		if _, err = patchAssignable(v, c.Type().Elem()); err != nil {
+			return err
+		}
+		if patchFinalChanged(c.Index(i), v) {
+			return ErrPatchFinalField
+		}
+		c.Index(i).Set(v)
+		return nil
+	}
+	return ErrPatchPathNotFound
+}
+
+// patchAdd inserts into slices and otherwise behaves like patchSet.
+func patchAdd(c reflect.Value, tok string, v reflect.Value) error {
+	if c.Kind() != reflect.Slice {
+		return patchSet(c, tok, v)
+	}
+	i, err := patchIndex(c, tok, true)
+	if err != nil {
+		return err
+	}
+	if _, err = patchAssignable(v, c.Type().Elem()); err != nil {
+		return err
+	}
+	grown := reflect.MakeSlice(c.Type(), 0, c.Len()+1)
+	grown = reflect.AppendSlice(grown, c.Slice(0, i))
+	grown = reflect.Append(grown, v)
+	grown = reflect.AppendSlice(grown, c.Slice(i, c.Len()))
+	c.Set(grown)
+	return nil
+}
+
+// patchRemove deletes map keys and slice elements, and resets struct fields to their zero value.
+func patchRemove(c reflect.Value, tok string) error {
+	switch c.Kind() {
+	case reflect.Struct:
+		f, fi, ok := patchStructField(c, tok)
+		if !ok {
+			return ErrPatchPathNotFound
+		}
+		if fi.Final || patchFinalChanged(f, reflect.Zero(f.Type())) {
+			return ErrPatchFinalField
+		}
+		f.Set(reflect.Zero(f.Type()))
+		return nil
+	case reflect.Map:
+		key, err := patchMapKey(c.Type(), tok)
+		if err != nil {
+			return err
+		}
+		old := c.MapIndex(key)
+		if !old.IsValid() {
+			return ErrPatchPathNotFound
+		}
+		if patchFinalChanged(old, reflect.Zero(old.Type())) {
+			return ErrPatchFinalField
+		}
+		c.SetMapIndex(key, reflect.Value{})
+		return nil
+	case reflect.Slice:
+		i, err := patchIndex(c, tok, false)
+		if err != nil {
+			return err
+		}
+		if patchFinalChanged(c.Index(i), reflect.Zero(c.Type().Elem())) {
+			return ErrPatchFinalField
+		}
+		shrunk := reflect.MakeSlice(c.Type(), 0, c.Len()-1)
+		shrunk = reflect.AppendSlice(shrunk, c.Slice(0, i))
+		shrunk = reflect.AppendSlice(shrunk, c.Slice(i+1, c.Len()))
+		c.Set(shrunk)
+		return nil
+	}
+	return ErrPatchPathNotFound
+}
+
+// patchFinalChanged reports whether replacing old with new, values of the same type,
+// would change a final field of a struct inside them, looking through nested structs
+// and pointers to them. A nil pointer stands for the zero struct.
+func patchFinalChanged(old, new reflect.Value) bool {
+	for old.Kind() == reflect.Ptr {
+		if old.IsNil() && new.IsNil() {
+			return false
+		}
+		old, new = patchPointee(old), patchPointee(new)
+	}
+	if old.Kind() != reflect.Struct {
+		return false
+	}
+	for i, n := 0, old.NumField(); i < n; i++ {
+		sf := old.Type().Field(i)
+		if !isExportedComponent(&sf) && !sf.Anonymous {
+			continue
+		}
+		of, nf := old.Field(i), new.Field(i)
+		if !parseField(sf).Final {
+			if patchFinalChanged(of, nf) {
+				return true
+			}
+			continue
+		}
+		if of.CanInterface() && !reflect.DeepEqual(of.Interface(), nf.Interface()) {
+			return true
+		}
+	}
+	return false
+}
+
+func patchPointee(p reflect.Value) reflect.Value {
+	if p.IsNil() {
+		return reflect.Zero(p.Type().Elem())
+	}
+	return p.Elem()
+}
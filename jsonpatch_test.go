@@ -0,0 +1,145 @@
+package mergo
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/davecgh/go-spew/spew"
+)
+
+type patchTestServer struct {
+	Host string `json:"host"`
+	Port int    `json:"port"`
+}
+
+type patchTestInner struct {
+	Key   string `json:"key" config:"final"`
+	Value string `json:"value"`
+}
+
+type patchTestConfig struct {
+	RequiredConfig
+	Name     string            `json:"name"`
+	Secret   string            `json:"secret" config:"final"`
+	Servers  []patchTestServer `json:"servers"`
+	Labels   map[string]string `json:"labels"`
+	Extra    map[string]interface{}
+	Primary  *patchTestServer `json:"primary"`
+	Inner    patchTestInner   `json:"inner"`
+	internal string
+}
+
+func newPatchTestConfig() patchTestConfig {
+	return patchTestConfig{
+		RequiredConfig: RequiredConfig{Environment: `dev`},
+		Name:           `svc`,
+		Secret:         `s3cr3t`,
+		Servers:        []patchTestServer{{`a`, 1}, {`b`, 2}},
+		Labels:         map[string]string{`team`: `core`},
+		Extra:          map[string]interface{}{`list`: []interface{}{`x`}},
+		Primary:        &patchTestServer{`p`, 80},
+		Inner:          patchTestInner{`k`, `v`},
+	}
+}
+
+func TestApplyJSONPatch(t *testing.T) {
+	cfg := newPatchTestConfig()
+	ops := `[
+		{"op": "test", "path": "/name", "value": "svc"},
+		{"op": "replace", "path": "/name", "value": "api"},
+		{"op": "add", "path": "/servers/1", "value": {"host": "c", "port": 3}},
+		{"op": "add", "path": "/servers/-", "value": {"host": "d", "port": 4}},
+		{"op": "remove", "path": "/servers/0"},
+		{"op": "replace", "path": "/primary/port", "value": 443},
+		{"op": "add", "path": "/labels/tier", "value": "web"},
+		{"op": "move", "from": "/labels/team", "path": "/labels/owner"},
+		{"op": "copy", "from": "/Environment", "path": "/LogLevel"},
+		{"op": "add", "path": "/Extra/list/-", "value": "y"}
+	]`
+	if err := ApplyJSONPatch(&cfg, []byte(ops)); err != nil {
+		t.Fatal(`error applying patch: ` + err.Error())
+	}
+	want := newPatchTestConfig()
+	want.Name = `api`
+	want.Servers = []patchTestServer{{`c`, 3}, {`b`, 2}, {`d`, 4}}
+	want.Primary.Port = 443
+	want.Labels = map[string]string{`owner`: `core`, `tier`: `web`}
+	want.LogLevel = `dev`
+	want.Extra = map[string]interface{}{`list`: []interface{}{`x`, `y`}}
+	if !reflect.DeepEqual(cfg, want) {
+		spew.Dump(cfg, want)
+		t.Fatal(`patch did not produce expected result`)
+	}
+}
+
+func TestApplyJSONPatchErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		ops  string
+		path string
+		err  error
+	}{
+		{`final field`, `[{"op": "replace", "path": "/secret", "value": "x"}]`, `/secret`, ErrPatchFinalField},
+		{`missing field`, `[{"op": "replace", "path": "/nope", "value": "x"}]`, `/nope`, ErrPatchPathNotFound},
+		{`unexported field`, `[{"op": "add", "path": "/internal", "value": "x"}]`, `/internal`, ErrPatchPathNotFound},
+		{`index out of range`, `[{"op": "remove", "path": "/servers/5"}]`, `/servers/5`, ErrPatchPathNotFound},
+		{`failed test`, `[{"op": "test", "path": "/servers/0/port", "value": 2}]`, `/servers/0/port`, ErrPatchTestFailed},
+		{`wrong type`, `[{"op": "replace", "path": "/name", "value": 7}]`, `/name`, ErrPatchTypeMismatch},
+		{`unknown op`, `[{"op": "merge", "path": "/name"}]`, `/name`, ErrPatchInvalidOperation},
+		{`remove document`, `[{"op": "remove", "path": ""}]`, ``, ErrPatchInvalidOperation},
+		{`replace document`, `[{"op": "replace", "path": "", "value": {"secret": "HACK", "inner": {"key": "HACK"}}}]`, ``, ErrPatchFinalField},
+		{`replace parent`, `[{"op": "replace", "path": "/inner", "value": {"key": "HACK", "value": "v"}}]`, `/inner`, ErrPatchFinalField},
+		{`remove parent`, `[{"op": "remove", "path": "/inner"}]`, `/inner`, ErrPatchFinalField},
+		{`case mismatch`, `[{"op": "replace", "path": "/Name", "value": "x"}]`, `/Name`, ErrPatchPathNotFound},
+		{`failed precondition`, `[{"op": "replace", "path": "/name", "value": "api"}, {"op": "test", "path": "/name", "value": "web"}]`, `/name`, ErrPatchTestFailed},
+		{`move onto final`, `[{"op": "move", "from": "/name", "path": "/secret"}]`, `/secret`, ErrPatchFinalField},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := newPatchTestConfig()
+			err := ApplyJSONPatch(&cfg, []byte(tt.ops))
+			var pe *PatchError
+			if !errors.As(err, &pe) || pe.Path != tt.path || !errors.Is(err, tt.err) {
+				t.Fatalf(`expected %v at %q, got %v`, tt.err, tt.path, err)
+			}
+			if !reflect.DeepEqual(cfg, newPatchTestConfig()) {
+				spew.Dump(cfg)
+				t.Fatal(`failed patch changed the document`)
+			}
+		})
+	}
+}
+
+func TestApplyJSONPatchKeepsFinalFields(t *testing.T) {
+	cfg := newPatchTestConfig()
+	ops := `[{"op": "replace", "path": "/inner", "value": {"key": "k", "value": "w"}}]`
+	if err := ApplyJSONPatch(&cfg, []byte(ops)); err != nil {
+		t.Fatal(`error applying patch: ` + err.Error())
+	}
+	if cfg.Inner != (patchTestInner{`k`, `w`}) {
+		t.Fatalf(`expected inner value to be replaced, got %+v`, cfg.Inner)
+	}
+}
+
+func TestApplyJSONPatchNonPointer(t *testing.T) {
+	if err := ApplyJSONPatch(newPatchTestConfig(), []byte(`[]`)); err != ErrNonPointerAgument {
+		t.Fatalf(`expected %v, got %v`, ErrNonPointerAgument, err)
+	}
+}
+
+func TestApplyJSONPatchTestNumbers(t *testing.T) {
+	doc := map[string]interface{}{`port`: 1, `ratio`: float32(0.5), `tags`: []interface{}{`a`, 2}}
+	ops := `[
+		{"op": "test", "path": "/port", "value": 1},
+		{"op": "test", "path": "/ratio", "value": 0.5},
+		{"op": "test", "path": "/tags", "value": ["a", 2.0]}
+	]`
+	if err := ApplyJSONPatch(&doc, []byte(ops)); err != nil {
+		t.Fatal(`error applying patch: ` + err.Error())
+	}
+	err := ApplyJSONPatch(&doc, []byte(`[{"op": "test", "path": "/port", "value": 2}]`))
+	if !errors.Is(err, ErrPatchTestFailed) {
+		t.Fatalf(`expected %v, got %v`, ErrPatchTestFailed, err)
+	}
+}
@@ -0,0 +1,333 @@
+package mergo
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v2"
+)
+
+// ErrUnknownFormat is returned, wrapped in a *LoadError, for a file no Decoder of the
+// Loader can decode.
+var ErrUnknownFormat = errors.New("unknown configuration file format")
+
+// LoadError reports a configuration file that could not be read or decoded.
+// Line is the line of the decode error in the file, or 0 if unknown.
+type LoadError struct {
+	Path string
+	Line int
+	Err  error
+}
+
+func (e *LoadError) Error() string {
+	if e.Line > 0 {
+		return fmt.Sprintf("%s:%d: %v", e.Path, e.Line, e.Err)
+	}
+	return fmt.Sprintf("%s: %v", e.Path, e.Err)
+}
+
+func (e *LoadError) Unwrap() error {
+	return e.Err
+}
+
+// Decoder decodes a configuration document into v, a pointer.
+type Decoder interface {
+	Decode(data []byte, v interface{}) error
+}
+
+// Sniffer is implemented by Decoders that can recognize their format from the content
+// of a file, for files whose extension is unknown.
+type Sniffer interface {
+	Sniff(data []byte) bool
+}
+
+// Decoders for the formats known to NewLoader.
+var (
+	YAMLDecoder Decoder = yamlDecoder{}
+	JSONDecoder Decoder = jsonDecoder{}
+	TOMLDecoder Decoder = tomlDecoder{}
+)
+
+// Loader loads layered configuration files, decoding each of them by its format.
+type Loader struct {
+	extensions map[string]Decoder
+	sniffers   []Decoder
+	fallback   Decoder
+}
+
+// NewLoader returns a Loader reading YAML (.yml, .yaml), JSON (.json) and TOML (.toml)
+// files. Files with another extension are recognized by their content, and decoded as
+// YAML if nothing else matches.
+func NewLoader() *Loader {
+	return (&Loader{fallback: YAMLDecoder}).
+		Register(JSONDecoder, `.json`).
+		Register(TOMLDecoder, `.toml`).
+		Register(YAMLDecoder, `.yml`, `.yaml`)
+}
+
+// Register makes l decode files with the given extensions, such as ".json", with d,
+// replacing the decoder they had. If d is a Sniffer, it is also offered the files whose
+// extension is unknown, after the decoders registered before it.
+func (l *Loader) Register(d Decoder, extensions ...string) *Loader {
+	if l.extensions == nil {
+		l.extensions = map[string]Decoder{}
+	}
+	for _, ext := range extensions {
+		l.extensions[strings.ToLower(ext)] = d
+	}
+	if _, ok := d.(Sniffer); ok {
+		for _, s := range l.sniffers {
+			if s == d {
+				return l
+			}
+		}
+		l.sniffers = append(l.sniffers, d)
+	}
+	return l
+}
+
+// Load decodes each file in paths into a fresh value of dst's type and merges them into
+// dst in order, WithOverride, so later files win over earlier ones and over what dst
+// already holds. As with Merge, empty values in a file never override: use Opt for
+// values that may be set to zero.
+// Final fields are never overridden: the first file setting one decides its value,
+// unless dst already holds one. Environment overrides win over every file.
+// A file that cannot be read or decoded stops the load with a *LoadError.
+func (l *Loader) Load(dst interface{}, paths ...string) error {
+	if dst == nil {
+		return ErrNilArguments
+	}
+	vDst := reflect.ValueOf(dst)
+	if vDst.Kind() != reflect.Ptr || vDst.IsNil() {
+		return ErrNonPointerAgument
+	}
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return &LoadError{Path: path, Err: err}
+		}
+		d := l.decoderFor(path, data)
+		if d == nil {
+			return &LoadError{Path: path, Err: ErrUnknownFormat}
+		}
+		src := reflect.New(vDst.Elem().Type())
+		if err = d.Decode(data, src.Interface()); err != nil {
+			return &LoadError{Path: path, Line: errorLine(err, data), Err: err}
+		}
+		if err = mergeLayer(vDst, src); err != nil {
+			return &LoadError{Path: path, Err: err}
+		}
+	}
+	return nil
+}
+
+// decoderFor picks the decoder for a file by its extension, then by its content.
+func (l *Loader) decoderFor(path string, data []byte) Decoder {
+	if d, ok := l.extensions[strings.ToLower(filepath.Ext(path))]; ok {
+		return d
+	}
+	for _, d := range l.sniffers {
+		if d.(Sniffer).Sniff(data) {
+			return d
+		}
+	}
+	return l.fallback
+}
+
+// BaseConfigName is the name, without extension, of the first file LoadDir loads.
+const BaseConfigName = `base`
+
+// ErrAmbiguousLayer is returned, wrapped in a *LoadError, when LoadDir finds the same
+// layer in several formats, e.g. prod.yml and prod.json.
+var ErrAmbiguousLayer = errors.New("configuration layer found in several formats")
+
+// LoadDir loads into dst, in order, the files of dir named base, then after env, then
+// after env and region joined by a dot, e.g. base.yml, prod.yml and prod.us-east.yml,
+// in any format of l, as Load does. Missing files are skipped. If env is empty, it is
+// taken from the Environment field of dst once base is loaded; region is only used
+// with an env.
+// Finally, if the OverrideConfigPath field of dst names a file, relative to dir unless
+// absolute, it is loaded last. The field is final, so no file can change it once set.
+func (l *Loader) LoadDir(dst interface{}, dir, env, region string) error {
+	if dst == nil {
+		return ErrNilArguments
+	}
+	if vDst := reflect.ValueOf(dst); vDst.Kind() != reflect.Ptr || vDst.IsNil() {
+		return ErrNonPointerAgument
+	}
+	if err := l.loadLayer(dst, dir, BaseConfigName); err != nil {
+		return err
+	}
+	if env == `` {
+		env = stringField(dst, `Environment`)
+	}
+	if env != `` {
+		if err := l.loadLayer(dst, dir, env); err != nil {
+			return err
+		}
+		if region != `` {
+			if err := l.loadLayer(dst, dir, env+`.`+region); err != nil {
+				return err
+			}
+		}
+	}
+	if path := stringField(dst, `OverrideConfigPath`); path != `` {
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(dir, path)
+		}
+		return l.Load(dst, path)
+	}
+	return nil
+}
+
+// loadLayer loads the file of dir named name in a format of l, if any.
+func (l *Loader) loadLayer(dst interface{}, dir, name string) error {
+	var found []string
+	for ext := range l.extensions {
+		path := filepath.Join(dir, name+ext)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			found = append(found, path)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return nil
+	case 1:
+		return l.Load(dst, found[0])
+	}
+	sort.Strings(found)
+	return &LoadError{Path: filepath.Join(dir, name), Err: fmt.Errorf("%w: %s", ErrAmbiguousLayer, strings.Join(found, `, `))}
+}
+
+// stringField returns the string field called name of the struct v points to, or "".
+func stringField(v interface{}, name string) string {
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	if rv.Kind() != reflect.Struct {
+		return ``
+	}
+	if f := rv.FieldByName(name); f.IsValid() && f.Kind() == reflect.String {
+		return f.String()
+	}
+	return ``
+}
+
+// LoadYAML loads the YAML files in paths into dst, whatever their extension, as
+// Loader.Load does.
+func LoadYAML(dst interface{}, paths ...string) error {
+	return (&Loader{fallback: YAMLDecoder}).Load(dst, paths...)
+}
+
+// LoadFiles loads the files in paths into dst with a NewLoader.
+func LoadFiles(dst interface{}, paths ...string) error {
+	return NewLoader().Load(dst, paths...)
+}
+
+// LoadDir loads the configuration files of dir into dst with a NewLoader, as
+// Loader.LoadDir does.
+func LoadDir(dst interface{}, dir, env, region string) error {
+	return NewLoader().LoadDir(dst, dir, env, region)
+}
+
+// mergeLayer merges the decoded src into dst, both pointers to the same type.
+func mergeLayer(dst, src reflect.Value) error {
+	fillFinal(dst.Elem(), src.Elem())
+	return merge(dst.Interface(), src.Interface(), WithOverride)
+}
+
+// fillFinal sets the final fields of dst that are still empty from src, since Merge
+// never touches final fields.
+func fillFinal(dst, src reflect.Value) {
+	if dst.Kind() != reflect.Struct {
+		return
+	}
+	for i, n := 0, dst.NumField(); i < n; i++ {
+		df, sf := dst.Field(i), src.Field(i)
+		if !df.CanSet() {
+			continue
+		}
+		if parseField(dst.Type().Field(i)).Final {
+			if isEmptyValue(df) && !isEmptyValue(sf) {
+				df.Set(sf)
+			}
+			continue
+		}
+		switch {
+		case df.Kind() == reflect.Struct:
+			fillFinal(df, sf)
+		case df.Kind() == reflect.Ptr && !df.IsNil() && !sf.IsNil():
+			fillFinal(df.Elem(), sf.Elem())
+		}
+	}
+}
+
+var errorLineRegexp = regexp.MustCompile(`line (\d+)`)
+
+// errorLine returns the line of a decode error in data, from the offset of a JSON
+// error or else the first line number in the message, or 0 if unknown.
+func errorLine(err error, data []byte) int {
+	var offset int64 = -1
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &syntaxErr) {
+		offset = syntaxErr.Offset
+	} else if errors.As(err, &typeErr) {
+		offset = typeErr.Offset
+	}
+	if offset >= 0 && offset <= int64(len(data)) {
+		return bytes.Count(data[:offset], []byte("\n")) + 1
+	}
+	if m := errorLineRegexp.FindStringSubmatch(err.Error()); m != nil {
+		line, _ := strconv.Atoi(m[1])
+		return line
+	}
+	return 0
+}
+
+type yamlDecoder struct{}
+
+func (yamlDecoder) Decode(data []byte, v interface{}) error {
+	return yaml.Unmarshal(data, v)
+}
+
+type jsonDecoder struct{}
+
+func (jsonDecoder) Decode(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+// Sniff recognizes a valid JSON object or array.
+func (jsonDecoder) Sniff(data []byte) bool {
+	data = bytes.TrimSpace(data)
+	return len(data) > 0 && (data[0] == '{' || data[0] == '[') && json.Valid(data)
+}
+
+type tomlDecoder struct{}
+
+func (tomlDecoder) Decode(data []byte, v interface{}) error {
+	return toml.Unmarshal(data, v)
+}
+
+var tomlLineRegexp = regexp.MustCompile(`^(\[[\w.\-" ]+\]|[\w\-"]+\s*=)`)
+
+// Sniff recognizes a document whose first line, past comments, is a table header or
+// a key = value pair, where YAML would have a colon.
+func (tomlDecoder) Sniff(data []byte) bool {
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == `` || strings.HasPrefix(line, `#`) {
+			continue
+		}
+		return tomlLineRegexp.MatchString(line)
+	}
+	return false
+}
@@ -0,0 +1,161 @@
+package mergo
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/davecgh/go-spew/spew"
+)
+
+type loadTestConfig struct {
+	RequiredConfig `yaml:",inline"`
+	Port           int              `yaml:"Port"`
+	Retries        Opt[int]         `yaml:"Retries"`
+	Database       loadTestDatabase `yaml:"Database"`
+}
+
+type loadTestDatabase struct {
+	Host string `yaml:"Host"`
+	Name string `yaml:"Name"`
+}
+
+func TestLoadYAML(t *testing.T) {
+	os.Setenv(`MSVC_LogLevel`, `debug`)
+	defer os.Unsetenv(`MSVC_LogLevel`)
+
+	var cfg loadTestConfig
+	if err := LoadYAML(&cfg, `testdata/load/base.yml`, `testdata/load/prod.yml`); err != nil {
+		t.Fatal(`error running LoadYAML: ` + err.Error())
+	}
+	want := loadTestConfig{
+		RequiredConfig: RequiredConfig{OverrideConfigPath: `/etc/service/override.yml`, Environment: `prod`, LogLevel: `debug`},
+		Port:           8080,
+		Retries:        Some(0),
+		Database:       loadTestDatabase{Host: `db.prod`, Name: `service`},
+	}
+	if !reflect.DeepEqual(cfg, want) {
+		spew.Dump(cfg)
+		t.Fatal(`LoadYAML did not merge the files in order`)
+	}
+}
+
+func TestLoadYAMLErrors(t *testing.T) {
+	var cfg loadTestConfig
+	err := LoadYAML(&cfg, `testdata/load/base.yml`, `testdata/load/broken.yml`)
+	var loadErr *LoadError
+	if !errors.As(err, &loadErr) {
+		t.Fatalf(`expected a *LoadError, got %v`, err)
+	}
+	if loadErr.Path != `testdata/load/broken.yml` || loadErr.Line != 2 {
+		spew.Dump(loadErr)
+		t.Fatal(`expected the error to point at line 2 of broken.yml`)
+	}
+
+	err = LoadYAML(&cfg, `testdata/load/missing.yml`)
+	if !errors.As(err, &loadErr) || !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf(`expected a *LoadError wrapping os.ErrNotExist, got %v`, err)
+	}
+
+	if err = LoadYAML(cfg, `testdata/load/base.yml`); err != ErrNonPointerAgument {
+		t.Fatalf(`expected ErrNonPointerAgument, got %v`, err)
+	}
+}
+
+func TestLoaderFormats(t *testing.T) {
+	var cfg loadTestConfig
+	err := NewLoader().Load(&cfg,
+		`testdata/load/base.yml`,
+		`testdata/load/override.json`,
+		`testdata/load/override.toml`,
+		`testdata/load/sniffed.conf`,
+	)
+	if err != nil {
+		t.Fatal(`error running Load: ` + err.Error())
+	}
+	want := loadTestConfig{
+		RequiredConfig: RequiredConfig{OverrideConfigPath: `/etc/service/override.yml`, Environment: `staging`, LogLevel: `error`},
+		Port:           7070,
+		Retries:        Some(5),
+		Database:       loadTestDatabase{Host: `db.staging`, Name: `json`},
+	}
+	if !reflect.DeepEqual(cfg, want) {
+		spew.Dump(cfg)
+		t.Fatal(`Load did not decode and merge every format`)
+	}
+
+	var loadErr *LoadError
+	err = NewLoader().Load(&cfg, `testdata/load/broken.json`)
+	if !errors.As(err, &loadErr) || loadErr.Line != 3 {
+		spew.Dump(err)
+		t.Fatal(`expected a *LoadError at line 3 of broken.json`)
+	}
+}
+
+type upperDecoder struct{}
+
+func (upperDecoder) Decode(data []byte, v interface{}) error {
+	return YAMLDecoder.Decode(bytes.ToUpper(data), v)
+}
+
+func (upperDecoder) Sniff(data []byte) bool {
+	return bytes.HasPrefix(data, []byte(`#!upper`))
+}
+
+func TestLoaderRegister(t *testing.T) {
+	loader := NewLoader().Register(upperDecoder{}, `.up`)
+	if d := loader.decoderFor(`app.up`, nil); d != (upperDecoder{}) {
+		t.Fatalf(`expected the registered decoder for .up files, got %T`, d)
+	}
+	if d := loader.decoderFor(`app`, []byte("#!upper\nPORT: 1")); d != (upperDecoder{}) {
+		t.Fatalf(`expected the registered decoder to sniff its files, got %T`, d)
+	}
+	if d := loader.decoderFor(`app`, []byte(`{"Port": 1}`)); d != JSONDecoder {
+		t.Fatalf(`expected JSON to be sniffed, got %T`, d)
+	}
+	if d := loader.decoderFor(`app`, []byte("Port: 1")); d != YAMLDecoder {
+		t.Fatalf(`expected YAML as the fallback, got %T`, d)
+	}
+}
+
+func TestLoadDir(t *testing.T) {
+	var cfg loadTestConfig
+	if err := LoadDir(&cfg, `testdata/dir`, `prod`, `us-east`); err != nil {
+		t.Fatal(`error running LoadDir: ` + err.Error())
+	}
+	want := loadTestConfig{
+		RequiredConfig: RequiredConfig{OverrideConfigPath: `local.yml`, Environment: `dev`, LogLevel: `warn`},
+		Port:           9090,
+		Database:       loadTestDatabase{Host: `db.us-east.prod`, Name: `service`},
+	}
+	if !reflect.DeepEqual(cfg, want) {
+		spew.Dump(cfg)
+		t.Fatal(`LoadDir did not merge base, env, region and override files in order`)
+	}
+
+	// Without an env, the Environment field set by base.yml picks dev.yml.
+	cfg = loadTestConfig{}
+	if err := LoadDir(&cfg, `testdata/dir`, ``, `us-east`); err != nil {
+		t.Fatal(`error running LoadDir: ` + err.Error())
+	}
+	if cfg.LogLevel != `debug` || cfg.Database.Host != `localhost` || cfg.Port != 9090 {
+		spew.Dump(cfg)
+		t.Fatal(`LoadDir did not fall back to the Environment field`)
+	}
+
+	err := LoadDir(&cfg, `testdata/dir-ambiguous`, ``, ``)
+	if !errors.Is(err, ErrAmbiguousLayer) {
+		t.Fatalf(`expected ErrAmbiguousLayer, got %v`, err)
+	}
+}
+
+func TestLoadDirArguments(t *testing.T) {
+	if err := LoadDir(nil, `testdata/dir`, ``, ``); err != ErrNilArguments {
+		t.Fatalf(`expected %v, got %v`, ErrNilArguments, err)
+	}
+	if err := LoadDir(loadTestConfig{}, `testdata/dir`, ``, ``); err != ErrNonPointerAgument {
+		t.Fatalf(`expected %v, got %v`, ErrNonPointerAgument, err)
+	}
+}
@@ -11,6 +11,8 @@ package mergo
 import (
 	"fmt"
 	"reflect"
+	"sort"
+	"strings"
 	"unicode"
 	"unicode/utf8"
 )
@@ -28,15 +30,457 @@ func isExported(field reflect.StructField) bool {
 	return r >= 'A' && r <= 'Z'
 }
 
+// mapKeyName returns the map key for a struct field: its name under the tag set
+// with WithTagName, or else the field name in lower camel case.
+func mapKeyName(field reflect.StructField, config *Config) (name string, omitEmpty, skip bool) {
+	if config.tagName != `` {
+		name, omitEmpty, skip = parseTagName(field, config.tagName)
+	}
+	if name == `` {
+		name = changeInitialCase(field.Name, unicode.ToLower)
+	}
+	return
+}
+
+// inlineField reports whether the fields of the struct (or pointer to struct) field sf
+// belong to the enclosing struct under tagName: when the tag has the inline option or,
+// except for yaml, when sf is embedded without a name in the tag, as with encoding/json.
+// An Opt is never inline.
+func inlineField(sf reflect.StructField, tagName string) bool {
+	typ := sf.Type
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if typ.Kind() != reflect.Struct || isOptionalType(typ) {
+		return false
+	}
+	tag := sf.Tag.Get(tagName)
+	if tag == `-` {
+		return false
+	}
+	parts := strings.Split(tag, `,`)
+	for _, opt := range parts[1:] {
+		if opt == `inline` {
+			return true
+		}
+	}
+	return sf.Anonymous && parts[0] == `` && tagName != `yaml`
+}
+
+// mapInlineField reports whether Map flattens the struct field sf, which it only does
+// for a tag set with WithTagName.
+func mapInlineField(sf reflect.StructField, config *Config) bool {
+	return config.tagName != `` && inlineField(sf, config.tagName)
+}
+
+// tagFieldName returns the key a struct field is known by under the given tag,
+// falling back to the Go field name. skip reports a "-" tag.
+func tagFieldName(sf reflect.StructField, tagName string) (name string, omitEmpty, skip bool) {
+	if name, omitEmpty, skip = parseTagName(sf, tagName); name == `` {
+		name = sf.Name
+	}
+	return
+}
+
+// parseTagName reads the name and options of a field under the given tag.
+// name is empty when the tag is missing or does not name the field.
+func parseTagName(sf reflect.StructField, tagName string) (name string, omitEmpty, skip bool) {
+	tag, ok := sf.Tag.Lookup(tagName)
+	if !ok {
+		return ``, false, false
+	}
+	if tag == `-` {
+		return ``, false, true
+	}
+	parts := strings.Split(tag, `,`)
+	for _, opt := range parts[1:] {
+		if opt == `omitempty` {
+			omitEmpty = true
+		}
+	}
+	return parts[0], omitEmpty, false
+}
+
+// KeyMatcher reports whether a map key refers to a struct field known to Map by name,
+// i.e. its name under the tag set with WithTagName, or its name in lower camel case.
+type KeyMatcher func(key, name string) bool
+
+// ExactKeyMatch matches keys spelled exactly like the field name.
+func ExactKeyMatch(key, name string) bool {
+	return key == name
+}
+
+// CaseInsensitiveKeyMatch matches keys regardless of case: LOGLEVEL and loglevel both match logLevel.
+func CaseInsensitiveKeyMatch(key, name string) bool {
+	return strings.EqualFold(key, name)
+}
+
+// NormalizedKeyMatch matches keys ignoring case and the separators _, -, . and spaces,
+// so log_level, log-level and Log.Level all match logLevel.
+func NormalizedKeyMatch(key, name string) bool {
+	return normalizeKey(key) == normalizeKey(name)
+}
+
+func normalizeKey(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '_', '-', '.', ' ':
+			return -1
+		}
+		return unicode.ToLower(r)
+	}, s)
+}
+
+// initialCaseKeyMatch is the default matching: the key only may differ in the case of its first letter.
+func initialCaseKeyMatch(key, name string) bool {
+	return changeInitialCase(name, unicode.ToUpper) == changeInitialCase(key, unicode.ToUpper)
+}
+
+// findMapField finds the exported field of the struct v that key maps to.
+// Fields of embedded structs are promoted, as with FieldByName. Keys are matched by
+// the KeyMatcher set with WithKeyMatcher, preferring a field named exactly as the key,
+// or else by the default rules.
+func findMapField(v reflect.Value, key string, config *Config) (reflect.Value, reflect.StructField, bool) {
+	if config.keyMatcher == nil {
+		return findMapFieldBy(v, key, config, initialCaseKeyMatch)
+	}
+	if f, field, ok := findMapFieldBy(v, key, config, ExactKeyMatch); ok {
+		return f, field, ok
+	}
+	return findMapFieldBy(v, key, config, config.keyMatcher)
+}
+
+func findMapFieldBy(v reflect.Value, key string, config *Config, match KeyMatcher) (reflect.Value, reflect.StructField, bool) {
+	var embedded []reflect.Value
+	for i, n := 0, v.NumField(); i < n; i++ {
+		field := v.Type().Field(i)
+		name, _, skip := mapKeyName(field, config)
+		if skip {
+			continue
+		}
+		if mapInlineField(field, config) {
+			if f := v.Field(i); f.Kind() == reflect.Struct {
+				embedded = append(embedded, f)
+			} else if !f.IsNil() {
+				embedded = append(embedded, f.Elem())
+			}
+			continue
+		}
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			embedded = append(embedded, v.Field(i))
+		}
+		if isExported(field) && match(key, name) {
+			return v.Field(i), field, true
+		}
+	}
+	for _, e := range embedded {
+		if f, field, ok := findMapFieldBy(e, key, config, match); ok {
+			return f, field, ok
+		}
+	}
+	return reflect.Value{}, reflect.StructField{}, false
+}
+
+// mapsIntoStructs reports whether a src slice or map has to be mapped element by element
+// into a dst slice or map of structs or pointers to structs.
+func mapsIntoStructs(srcType, dstType reflect.Type) bool {
+	if srcType.AssignableTo(dstType) {
+		return false
+	}
+	switch {
+	case dstType.Kind() == reflect.Slice && (srcType.Kind() == reflect.Slice || srcType.Kind() == reflect.Array):
+	case dstType.Kind() == reflect.Map && srcType.Kind() == reflect.Map:
+	default:
+		return false
+	}
+	elem := dstType.Elem()
+	if elem.Kind() == reflect.Ptr {
+		elem = elem.Elem()
+	}
+	return elem.Kind() == reflect.Struct
+}
+
+// mapCollection maps the elements of the src slice or map into the dst slice or map of
+// structs. Slices are replaced as a whole, like Merge does; map entries already in dst
+// are mapped into, so their other fields are kept.
+func mapCollection(dst, src reflect.Value, path string, walk *walkState, depth int, config *Config) error {
+	elemType := dst.Type().Elem()
+	if dst.Kind() == reflect.Slice {
+		if !isEmpty(dst, config) && !config.Overwrite {
+			return nil
+		}
+		s := reflect.MakeSlice(dst.Type(), src.Len(), src.Len())
+		for i := 0; i < src.Len(); i++ {
+			e, err := mapElement(elemType, src.Index(i), reflect.Value{}, joinIndexPath(path, i), walk, depth, config)
+			if err != nil {
+				return err
+			}
+			s.Index(i).Set(e)
+		}
+		dst.Set(s)
+		return nil
+	}
+	if dst.Type().Key().Kind() != reflect.String {
+		return fmt.Errorf("cannot map into %s: keys must be strings", dst.Type())
+	}
+	if dst.IsNil() {
+		dst.Set(reflect.MakeMap(dst.Type()))
+	}
+	for _, srcKey := range src.MapKeys() {
+		key := reflect.ValueOf(mapKeyString(srcKey)).Convert(dst.Type().Key())
+		e, err := mapElement(elemType, src.MapIndex(srcKey), dst.MapIndex(key), joinFieldPath(path, key.String()), walk, depth, config)
+		if err != nil {
+			return err
+		}
+		dst.SetMapIndex(key, e)
+	}
+	return nil
+}
+
+// mapElement maps a single src element into a new value of typ, starting from existing if valid.
+// Maps are mapped into structs and pointers to structs, allocating them as needed.
+func mapElement(typ reflect.Type, src, existing reflect.Value, path string, walk *walkState, depth int, config *Config) (reflect.Value, error) {
+	elem := reflect.New(typ).Elem()
+	if existing.IsValid() {
+		elem.Set(existing)
+	}
+	if src.Kind() == reflect.Interface {
+		src = src.Elem()
+	}
+	if !src.IsValid() {
+		return elem, nil
+	}
+	src = reflect.ValueOf(normalizeMapValue(src.Interface()))
+	if src.Kind() == reflect.Map {
+		if typ.Kind() == reflect.Ptr && elem.IsNil() {
+			elem.Set(reflect.New(typ.Elem()))
+		}
+		parentPath := walk.mapPath
+		walk.mapPath = path
+		err := deepMap(elem, src, walk, depth+1, config)
+		walk.mapPath = parentPath
+		return elem, err
+	}
+	if src.Type().AssignableTo(typ) {
+		elem.Set(src)
+		return elem, nil
+	}
+	if config.weaklyTypedInput {
+		if coerced, ok := coerceValue(src, typ); ok {
+			elem.Set(coerced)
+			return elem, nil
+		}
+	}
+	return elem, fmt.Errorf("type mismatch on %s: found %v, expected %v", path, src.Type(), typ)
+}
+
+// mapOptional sets the Opt behind opt from a src value of its value type, unless it is
+// already set and the mapping runs without WithOverride.
+func mapOptional(opt optional, src reflect.Value, path string, config *Config) error {
+	if _, set := opt.optionalValue(); set && !config.Overwrite {
+		return nil
+	}
+	typ := opt.optionalType()
+	if !src.Type().AssignableTo(typ) {
+		coerced, ok := coerceValue(src, typ)
+		if !ok || !config.weaklyTypedInput {
+			return fmt.Errorf("type mismatch on %s: found %v, expected %v", path, src.Type(), typ)
+		}
+		src = coerced
+	}
+	opt.setOptional(copyValue(src, config))
+	return nil
+}
+
+// mapKeyString returns the string a map key stands for; maps decoded from YAML
+// have interface{} keys.
+func mapKeyString(key reflect.Value) string {
+	if key.Kind() == reflect.Interface {
+		key = key.Elem()
+	}
+	if key.Kind() == reflect.String {
+		return key.String()
+	}
+	return fmt.Sprint(key.Interface())
+}
+
+// normalizeMapValue turns maps with interface{} keys, as produced by gopkg.in/yaml.v2,
+// into map[string]interface{}, recursively, also inside []interface{}.
+func normalizeMapValue(v interface{}) interface{} {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.Interface {
+			return v
+		}
+		m := make(map[string]interface{}, rv.Len())
+		for _, k := range rv.MapKeys() {
+			m[mapKeyString(k)] = normalizeMapValue(rv.MapIndex(k).Interface())
+		}
+		return m
+	case reflect.Slice:
+		if rv.Type().Elem().Kind() != reflect.Interface {
+			return v
+		}
+		s := make([]interface{}, rv.Len())
+		for i := range s {
+			s[i] = normalizeMapValue(rv.Index(i).Interface())
+		}
+		return s
+	}
+	return v
+}
+
+// nestedMapValue converts structs, pointers to structs and slices, arrays and maps
+// holding them into map[string]interface{} and []interface{} values, keyed like Map does.
+// Structs without exported fields, such as time.Time, are kept as they are, and an Opt
+// stands for its value, or nil if it is not set.
+// A pointer leading back to a struct being converted is mapped to nil.
+func nestedMapValue(v reflect.Value, config *Config, seen map[uintptr]bool) interface{} {
+	if opt, ok := asOptional(v); ok {
+		value, set := opt.optionalValue()
+		if !set {
+			return nil
+		}
+		return nestedMapValue(value, config, seen)
+	}
+	if !holdsStructs(v.Type()) {
+		return copyValue(v, config).Interface()
+	}
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() || seen[v.Pointer()] {
+			return nil
+		}
+		seen[v.Pointer()] = true
+		defer delete(seen, v.Pointer())
+		return nestedMapValue(v.Elem(), config, seen)
+	case reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return nestedMapValue(v.Elem(), config, seen)
+	case reflect.Struct:
+		m := make(map[string]interface{}, v.NumField())
+		nestedStructFields(m, v, config, seen)
+		return m
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return []interface{}(nil)
+		}
+		s := make([]interface{}, v.Len())
+		for i := range s {
+			s[i] = nestedMapValue(v.Index(i), config, seen)
+		}
+		return s
+	case reflect.Map:
+		if v.IsNil() || v.Type().Key().Kind() != reflect.String {
+			break
+		}
+		m := make(map[string]interface{}, v.Len())
+		for _, k := range v.MapKeys() {
+			m[k.String()] = nestedMapValue(v.MapIndex(k), config, seen)
+		}
+		return m
+	}
+	return copyValue(v, config).Interface()
+}
+
+// nestedStructFields stores the fields of the struct v into m, flattening inline ones.
+func nestedStructFields(m map[string]interface{}, v reflect.Value, config *Config, seen map[uintptr]bool) {
+	for i, n := 0, v.NumField(); i < n; i++ {
+		field := v.Type().Field(i)
+		if !isExported(field) {
+			continue
+		}
+		if mapInlineField(field, config) {
+			if f := reflect.Indirect(v.Field(i)); f.IsValid() {
+				nestedStructFields(m, f, config, seen)
+			}
+			continue
+		}
+		name, omitEmpty, skip := mapKeyName(field, config)
+		if skip || (omitEmpty && isEmpty(v.Field(i), config)) {
+			continue
+		}
+		m[name] = nestedMapValue(v.Field(i), config, seen)
+	}
+}
+
+// holdsStructs reports whether values of typ may contain structs nestedMapValue converts.
+func holdsStructs(typ reflect.Type) bool {
+	switch typ.Kind() {
+	case reflect.Interface:
+		return true
+	case reflect.Ptr, reflect.Slice, reflect.Array, reflect.Map:
+		return holdsStructs(typ.Elem())
+	case reflect.Struct:
+		return hasMergeableFields(reflect.Zero(typ))
+	}
+	return false
+}
+
+// mapStructFields stores the fields of the struct src into the map dst, flattening
+// inline ones.
+func mapStructFields(dst, src reflect.Value, config *Config) error {
+	elemType := dst.Type().Elem()
+	for i, n := 0, src.NumField(); i < n; i++ {
+		srcType := src.Type()
+		field := srcType.Field(i)
+		if !isExported(field) {
+			continue
+		}
+		if mapInlineField(field, config) {
+			if f := reflect.Indirect(src.Field(i)); f.IsValid() {
+				if err := mapStructFields(dst, f, config); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		fieldName, omitEmpty, skip := mapKeyName(field, config)
+		if skip || (omitEmpty && isEmpty(src.Field(i), config)) {
+			continue
+		}
+		key := reflect.ValueOf(fieldName).Convert(dst.Type().Key())
+		if v := dst.MapIndex(key); !v.IsValid() || isEmpty(v, config) || config.Overwrite {
+			var value reflect.Value
+			if opt, ok := asOptional(src.Field(i)); ok {
+				// an unset Opt is left out, a set one stands for its value
+				var set bool
+				if value, set = opt.optionalValue(); !set {
+					continue
+				}
+				value = copyValue(value, config)
+			} else if config.nestedMaps {
+				value = reflect.ValueOf(nestedMapValue(src.Field(i), config, map[uintptr]bool{}))
+			} else {
+				value = copyValue(src.Field(i), config)
+			}
+			if !value.IsValid() {
+				value = reflect.Zero(elemType)
+			}
+			if !value.Type().AssignableTo(elemType) {
+				coerced, ok := coerceValue(value, elemType)
+				if !ok || !config.weaklyTypedInput {
+					return fmt.Errorf("type mismatch on %s field: found %v, expected %v", field.Name, value.Type(), elemType)
+				}
+				value = coerced
+			}
+			dst.SetMapIndex(key, value)
+		}
+	}
+	return nil
+}
+
 // Traverses recursively both values, assigning src's fields values to dst.
-// The map argument tracks comparisons that have already been seen, which allows
+// walk tracks the comparisons that have already been seen, which allows
 // short circuiting on recursive types.
-func deepMap(dst, src reflect.Value, visited map[uintptr]*visit, depth int, config *Config) (err error) {
-	overwrite := config.Overwrite
+func deepMap(dst, src reflect.Value, walk *walkState, depth int, config *Config) (err error) {
 	if dst.CanAddr() {
 		addr := dst.UnsafeAddr()
 		h := 17 * addr
-		seen := visited[h]
+		seen := walk.visited[h]
 		typ := dst.Type()
 		for p := seen; p != nil; p = p.next {
 			if p.ptr == addr && p.typ == typ {
@@ -44,23 +488,18 @@ func deepMap(dst, src reflect.Value, visited map[uintptr]*visit, depth int, conf
 			}
 		}
 		// Remember, remember...
-		visited[h] = &visit{addr, typ, seen}
+		walk.visited[h] = &visit{addr, typ, seen}
 	}
-	zeroValue := reflect.Value{}
 	switch dst.Kind() {
 	case reflect.Map:
-		dstMap := dst.Interface().(map[string]interface{})
-		for i, n := 0, src.NumField(); i < n; i++ {
-			srcType := src.Type()
-			field := srcType.Field(i)
-			if !isExported(field) {
-				continue
-			}
-			fieldName := field.Name
-			fieldName = changeInitialCase(fieldName, unicode.ToLower)
-			if v, ok := dstMap[fieldName]; !ok || (isEmptyValue(reflect.ValueOf(v)) || overwrite) {
-				dstMap[fieldName] = src.Field(i).Interface()
-			}
+		if dst.Type().Key().Kind() != reflect.String {
+			return fmt.Errorf("cannot map into %s: keys must be strings", dst.Type())
+		}
+		if dst.IsNil() {
+			dst.Set(reflect.MakeMap(dst.Type()))
+		}
+		if err = mapStructFields(dst, src, config); err != nil {
+			return
 		}
 	case reflect.Ptr:
 		if dst.IsNil() {
@@ -70,27 +509,30 @@ func deepMap(dst, src reflect.Value, visited map[uintptr]*visit, depth int, conf
 		dst = dst.Elem()
 		fallthrough
 	case reflect.Struct:
-		srcMap := src.Interface().(map[string]interface{})
-		for key := range srcMap {
+		for _, srcKey := range src.MapKeys() {
 			config.overwriteWithEmptyValue = true
-			srcValue := srcMap[key]
-			fieldName := changeInitialCase(key, unicode.ToUpper)
-			dstElement := dst.FieldByName(fieldName)
-			if dstElement == zeroValue {
+			key := mapKeyString(srcKey)
+			srcValue := normalizeMapValue(src.MapIndex(srcKey).Interface())
+			keyPath := joinFieldPath(walk.mapPath, key)
+			dstElement, field, found := findMapField(dst, key, config)
+			if !found {
 				// We discard it because the field doesn't exist.
+				walk.unknownKeys = append(walk.unknownKeys, keyPath)
 				continue
 			}
+			fieldName := field.Name
 			srcElement := reflect.ValueOf(srcValue)
 			dstKind := dstElement.Kind()
 			srcKind := srcElement.Kind()
 			if srcKind == reflect.Ptr && dstKind != reflect.Ptr {
 				srcElement = srcElement.Elem()
 				srcKind = reflect.TypeOf(srcElement.Interface()).Kind()
-			} else if dstKind == reflect.Ptr {
-				// Can this work? I guess it can't.
-				if srcKind != reflect.Ptr && srcElement.CanAddr() {
-					srcPtr := srcElement.Addr()
-					srcElement = reflect.ValueOf(srcPtr)
+			} else if dstKind == reflect.Ptr && srcKind != reflect.Ptr && srcKind != reflect.Map && srcElement.IsValid() {
+				// point to a copy of the value, so it can be merged like any other pointer
+				if srcElement.Type().AssignableTo(dstElement.Type().Elem()) {
+					srcPtr := reflect.New(dstElement.Type().Elem())
+					srcPtr.Elem().Set(srcElement)
+					srcElement = srcPtr
 					srcKind = reflect.Ptr
 				}
 			}
@@ -98,16 +540,35 @@ func deepMap(dst, src reflect.Value, visited map[uintptr]*visit, depth int, conf
 			if !srcElement.IsValid() {
 				continue
 			}
-			if srcKind == dstKind {
-				if err = deepMerge(dstElement, srcElement, visited, depth+1, config); err != nil {
+			if opt, ok := asOptional(dstElement); ok {
+				if err = mapOptional(opt, srcElement, keyPath, config); err != nil {
+					return
+				}
+				continue
+			}
+			if config.weaklyTypedInput && !srcElement.Type().AssignableTo(dstElement.Type()) {
+				if coerced, ok := coerceValue(srcElement, dstElement.Type()); ok {
+					srcElement, srcKind = coerced, coerced.Kind()
+				}
+			}
+			if mapsIntoStructs(srcElement.Type(), dstElement.Type()) {
+				if err = mapCollection(dstElement, srcElement, keyPath, walk, depth+1, config); err != nil {
+					return
+				}
+			} else if srcKind == dstKind {
+				if err = deepMerge(dstElement, srcElement, walk, depth+1, config); err != nil {
 					return
 				}
 			} else if dstKind == reflect.Interface && dstElement.Kind() == reflect.Interface {
-				if err = deepMerge(dstElement, srcElement, visited, depth+1, config); err != nil {
+				if err = deepMerge(dstElement, srcElement, walk, depth+1, config); err != nil {
 					return
 				}
 			} else if srcKind == reflect.Map {
-				if err = deepMap(dstElement, srcElement, visited, depth+1, config); err != nil {
+				parentPath := walk.mapPath
+				walk.mapPath = keyPath
+				err = deepMap(dstElement, srcElement, walk, depth+1, config)
+				walk.mapPath = parentPath
+				if err != nil {
 					return
 				}
 			} else {
@@ -121,26 +582,61 @@ func deepMap(dst, src reflect.Value, visited map[uintptr]*visit, depth int, conf
 // Map sets fields' values in dst from src.
 // src can be a map with string keys or a struct. dst must be the opposite:
 // if src is a map, dst must be a valid pointer to struct. If src is a struct,
-// dst must be map[string]interface{}.
+// dst must be a pointer to a map with string keys, such as map[string]interface{}
+// or map[string]string. Maps with interface{} keys, as decoded by gopkg.in/yaml.v2,
+// are accepted as src and converted to map[string]interface{} on the way.
 // It won't merge unexported (private) fields and will do recursively
 // any exported field.
 // If dst is a map, keys will be src fields' names in lower camel case.
-// Missing key in src that doesn't match a field in dst will be skipped. This
-// doesn't apply if dst is a map.
+// Nested maps are mapped into struct fields, pointers to structs (allocating them),
+// and, element by element, into slices and maps of structs.
+// Missing key in src that doesn't match a field in dst will be skipped, unless
+// WithStrictKeys is used. This doesn't apply if dst is a map.
+// Nested structs are stored as they are unless WithNestedMaps is used.
+// WithWeaklyTypedInput converts values of the wrong type, e.g. "8080" into an int field.
+// WithKeyMatcher loosens how keys are matched to fields, e.g. with NormalizedKeyMatch.
+// Use WithTagName to name keys after a struct tag such as json or yaml instead,
+// honoring its omitempty, "-" and inline options in both directions. Embedded structs
+// without a name in the tag are inline too, except for yaml. A nil pointer to an
+// inline struct is not allocated, so its keys are unknown.
 // This is separated method from Merge because it is cleaner and it keeps sane
 // semantics: merging equal types, mapping different (restricted) types.
 func Map(dst, src interface{}, opts ...func(*Config)) error {
-	return _map(dst, src, opts...)
+	return _map(dst, src, nil, opts...)
+}
+
+// MapResult reports what Map could not apply.
+type MapResult struct {
+	// UnknownKeys lists the src keys matching no field, as dotted paths for nested maps.
+	UnknownKeys []string
+}
+
+// UnknownKeysError is returned by Map used WithStrictKeys when src has keys matching no field.
+type UnknownKeysError struct {
+	Keys []string
+}
+
+func (e *UnknownKeysError) Error() string {
+	return fmt.Sprintf("unknown keys: %s", strings.Join(e.Keys, ", "))
+}
+
+// MapWithResult does the same as Map and also returns the keys of src it could not
+// map into dst, instead of discarding them silently.
+func MapWithResult(dst, src interface{}, opts ...func(*Config)) (*MapResult, error) {
+	result := &MapResult{}
+	err := _map(dst, src, result, opts...)
+	return result, err
 }
 
 // MapWithOverwrite will do the same as Map except that non-empty dst attributes will be overridden by
 // non-empty src attribute values.
 // Deprecated: Use Map(…) with WithOverride
 func MapWithOverwrite(dst, src interface{}, opts ...func(*Config)) error {
-	return _map(dst, src, append(opts, WithOverride)...)
+	return _map(dst, src, nil, append(opts, WithOverride)...)
 }
 
-func _map(dst, src interface{}, opts ...func(*Config)) error {
+// _map maps src into dst, reporting the keys it could not map in result if not nil.
+func _map(dst, src interface{}, result *MapResult, opts ...func(*Config)) error {
 	if dst != nil && reflect.ValueOf(dst).Kind() != reflect.Ptr {
 		return ErrNonPointerAgument
 	}
@@ -160,7 +656,13 @@ func _map(dst, src interface{}, opts ...func(*Config)) error {
 	// To be friction-less, we redirect equal-type arguments
 	// to deepMerge. Only because arguments can be anything.
 	if vSrc.Kind() == vDst.Kind() {
-		return deepMerge(vDst, vSrc, make(map[uintptr]*visit), 0, config)
+		if err = deepMerge(vDst, vSrc, newWalkState(), 0, config); err != nil {
+			return err
+		}
+		if config.defaults {
+			return applyDefaults(vDst, ``, config)
+		}
+		return nil
 	}
 	switch vSrc.Kind() {
 	case reflect.Struct:
@@ -174,5 +676,21 @@ func _map(dst, src interface{}, opts ...func(*Config)) error {
 	default:
 		return ErrNotSupported
 	}
-	return deepMap(vDst, vSrc, make(map[uintptr]*visit), 0, config)
+	walk := newWalkState()
+	if err = deepMap(vDst, vSrc, walk, 0, config); err != nil {
+		return err
+	}
+	if config.defaults {
+		if err = applyDefaults(vDst, ``, config); err != nil {
+			return err
+		}
+	}
+	sort.Strings(walk.unknownKeys)
+	if result != nil {
+		result.UnknownKeys = walk.unknownKeys
+	}
+	if config.strictKeys && len(walk.unknownKeys) > 0 {
+		return &UnknownKeysError{Keys: walk.unknownKeys}
+	}
+	return nil
 }
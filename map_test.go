@@ -0,0 +1,408 @@
+package mergo
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/davecgh/go-spew/spew"
+	"gopkg.in/yaml.v2"
+)
+
+type mapTestConfig struct {
+	RequiredConfig
+	URL      string `json:"url" yaml:"URL"`
+	Timeout  int    `json:"timeout,omitempty"`
+	Password string `json:"-"`
+	Region   string
+}
+
+func TestMapWithTagName(t *testing.T) {
+	src := mapTestConfig{
+		RequiredConfig: RequiredConfig{LogLevel: `debug`},
+		URL:            `http://localhost`,
+		Password:       `hunter2`,
+		Region:         `us-east`,
+	}
+	dst := map[string]interface{}{}
+	if err := Map(&dst, src, WithTagName(`json`)); err != nil {
+		t.Fatal(`error running Map: ` + err.Error())
+	}
+	want := map[string]interface{}{
+		`overrideConfigPath`: ``,
+		`environment`:        ``,
+		`logLevel`:           `debug`,
+		`version`:            ``,
+		`branch`:             ``,
+		`commit`:             ``,
+		`imageTag`:           ``,
+		`build`:              ``,
+		`dateBuilt`:          ``,
+		`url`:                `http://localhost`,
+		`region`:             `us-east`,
+	}
+	if !reflect.DeepEqual(dst, want) {
+		spew.Dump(dst)
+		t.Fatal(`Map did not use json tag names for keys`)
+	}
+
+	cfg := mapTestConfig{}
+	srcMap := map[string]interface{}{
+		`url`:      `http://example.com`,
+		`timeout`:  5,
+		`password`: `leaked`,
+		`Password`: `leaked`,
+		`region`:   `eu-west`,
+	}
+	if err := Map(&cfg, srcMap, WithTagName(`json`)); err != nil {
+		t.Fatal(`error running Map: ` + err.Error())
+	}
+	if cfg.URL != `http://example.com` || cfg.Timeout != 5 || cfg.Password != `` || cfg.Region != `eu-west` {
+		spew.Dump(cfg)
+		t.Fatal(`Map did not find fields by json tag name`)
+	}
+
+	cfg = mapTestConfig{}
+	yamlMap := map[string]interface{}{`URL`: `http://yaml`, `LogLevel`: `warn`}
+	if err := Map(&cfg, yamlMap, WithTagName(`yaml`)); err != nil {
+		t.Fatal(`error running Map: ` + err.Error())
+	}
+	if cfg.URL != `http://yaml` || cfg.LogLevel != `warn` {
+		spew.Dump(cfg)
+		t.Fatal(`Map did not find fields by yaml tag name`)
+	}
+}
+
+type mapTestInlineConfig struct {
+	RequiredConfig `yaml:",inline"`
+	Database       *mapTestServer `yaml:",inline"`
+	URL            string         `yaml:"URL"`
+}
+
+func TestMapInline(t *testing.T) {
+	src := mapTestInlineConfig{
+		RequiredConfig: RequiredConfig{Environment: `prod`},
+		Database:       &mapTestServer{Host: `db`},
+		URL:            `http://localhost`,
+	}
+	dst := map[string]interface{}{}
+	if err := Map(&dst, src, WithTagName(`yaml`), WithNestedMaps); err != nil {
+		t.Fatal(`error running Map: ` + err.Error())
+	}
+	if _, nested := dst[`requiredConfig`]; nested || dst[`Environment`] != `prod` || dst[`host`] != `db` || dst[`URL`] != `http://localhost` {
+		spew.Dump(dst)
+		t.Fatal(`Map did not flatten inline structs`)
+	}
+
+	cfg := mapTestInlineConfig{Database: &mapTestServer{}}
+	if err := Map(&cfg, dst, WithTagName(`yaml`)); err != nil {
+		t.Fatal(`error running Map: ` + err.Error())
+	}
+	if !reflect.DeepEqual(cfg, src) {
+		spew.Dump(cfg)
+		t.Fatal(`Map did not fill inline structs back`)
+	}
+}
+
+type mapTestServer struct {
+	Host string `json:"host"`
+	Port int    `json:"port,omitempty"`
+}
+
+type mapTestNested struct {
+	Name    string          `json:"name"`
+	Primary *mapTestServer  `json:"primary"`
+	Backup  *mapTestServer  `json:"backup"`
+	Servers []mapTestServer `json:"servers"`
+	ByName  map[string]*mapTestServer
+	Self    *mapTestNested
+}
+
+func TestMapWithNestedMaps(t *testing.T) {
+	src := mapTestNested{
+		Name:    `svc`,
+		Primary: &mapTestServer{`a`, 80},
+		Servers: []mapTestServer{{`b`, 0}, {`c`, 8080}},
+		ByName:  map[string]*mapTestServer{`d`: {Host: `d`}},
+	}
+	src.Self = &src
+	dst := map[string]interface{}{}
+	if err := Map(&dst, src, WithNestedMaps, WithTagName(`json`)); err != nil {
+		t.Fatal(`error running Map: ` + err.Error())
+	}
+	want := map[string]interface{}{
+		`name`:    `svc`,
+		`primary`: map[string]interface{}{`host`: `a`, `port`: 80},
+		`backup`:  nil,
+		`servers`: []interface{}{
+			map[string]interface{}{`host`: `b`},
+			map[string]interface{}{`host`: `c`, `port`: 8080},
+		},
+		`byName`: map[string]interface{}{`d`: map[string]interface{}{`host`: `d`}},
+		`self`: map[string]interface{}{
+			`name`:    `svc`,
+			`primary`: map[string]interface{}{`host`: `a`, `port`: 80},
+			`backup`:  nil,
+			`servers`: []interface{}{
+				map[string]interface{}{`host`: `b`},
+				map[string]interface{}{`host`: `c`, `port`: 8080},
+			},
+			`byName`: map[string]interface{}{`d`: map[string]interface{}{`host`: `d`}},
+			`self`:   nil,
+		},
+	}
+	if !reflect.DeepEqual(dst, want) {
+		spew.Dump(dst)
+		t.Fatal(`Map did not convert nested structs into maps`)
+	}
+}
+
+type mapTestKeys struct {
+	LogLevel  string
+	Log_level string
+	MaxConns  int
+}
+
+func TestMapWithKeyMatcher(t *testing.T) {
+	src := map[string]interface{}{`log_level`: `debug`, `LOGLEVEL`: `info`, `max-conns`: 5}
+
+	cfg := mapTestKeys{}
+	if err := Map(&cfg, src); err != nil {
+		t.Fatal(`error running Map: ` + err.Error())
+	}
+	if !reflect.DeepEqual(cfg, mapTestKeys{Log_level: `debug`}) {
+		spew.Dump(cfg)
+		t.Fatal(`default matching should only ignore the case of the first letter`)
+	}
+
+	cfg = mapTestKeys{}
+	if err := Map(&cfg, src, WithKeyMatcher(CaseInsensitiveKeyMatch)); err != nil {
+		t.Fatal(`error running Map: ` + err.Error())
+	}
+	if !reflect.DeepEqual(cfg, mapTestKeys{LogLevel: `info`, Log_level: `debug`}) {
+		spew.Dump(cfg)
+		t.Fatal(`case-insensitive matching did not find LOGLEVEL`)
+	}
+
+	cfg = mapTestKeys{}
+	if err := Map(&cfg, map[string]interface{}{`max-conns`: 5, `LOG-LEVEL`: `warn`}, WithKeyMatcher(NormalizedKeyMatch)); err != nil {
+		t.Fatal(`error running Map: ` + err.Error())
+	}
+	if !reflect.DeepEqual(cfg, mapTestKeys{LogLevel: `warn`, MaxConns: 5}) {
+		spew.Dump(cfg)
+		t.Fatal(`normalized matching did not find kebab-case keys`)
+	}
+
+	cfg = mapTestKeys{}
+	if err := Map(&cfg, map[string]interface{}{`LogLevel`: `warn`, `maxConns`: 5}, WithKeyMatcher(ExactKeyMatch)); err != nil {
+		t.Fatal(`error running Map: ` + err.Error())
+	}
+	if !reflect.DeepEqual(cfg, mapTestKeys{MaxConns: 5}) {
+		spew.Dump(cfg)
+		t.Fatal(`exact matching should reject keys whose case differs`)
+	}
+
+	cfg = mapTestKeys{}
+	custom := func(key, name string) bool { return `cfg.`+name == key }
+	if err := Map(&cfg, map[string]interface{}{`cfg.maxConns`: 7}, WithKeyMatcher(custom)); err != nil {
+		t.Fatal(`error running Map: ` + err.Error())
+	}
+	if cfg.MaxConns != 7 {
+		spew.Dump(cfg)
+		t.Fatal(`custom matcher was not used`)
+	}
+}
+
+func TestMapUnknownKeys(t *testing.T) {
+	src := map[string]interface{}{
+		`name`:    `svc`,
+		`nmae`:    `typo`,
+		`primary`: map[string]interface{}{`host`: `a`, `prot`: 80},
+	}
+
+	cfg := mapTestNested{}
+	result, err := MapWithResult(&cfg, src)
+	if err != nil {
+		t.Fatal(`error running MapWithResult: ` + err.Error())
+	}
+	if cfg.Name != `svc` || cfg.Primary == nil || cfg.Primary.Host != `a` {
+		spew.Dump(cfg)
+		t.Fatal(`MapWithResult did not map the known keys`)
+	}
+	want := []string{`nmae`, `primary.prot`}
+	if !reflect.DeepEqual(result.UnknownKeys, want) {
+		spew.Dump(result)
+		t.Fatal(`MapWithResult did not report the unknown keys`)
+	}
+
+	cfg = mapTestNested{}
+	err = Map(&cfg, src, WithStrictKeys)
+	if uerr, ok := err.(*UnknownKeysError); !ok || !reflect.DeepEqual(uerr.Keys, want) {
+		t.Fatalf(`expected an UnknownKeysError for %v, got %v`, want, err)
+	}
+	if err.Error() != `unknown keys: nmae, primary.prot` {
+		t.Fatalf(`unexpected error message %q`, err.Error())
+	}
+}
+
+type mapTestTyped struct {
+	Port    int
+	Ratio   float64
+	Debug   bool
+	Timeout time.Duration
+	Hosts   []string
+	Retries *int
+}
+
+func TestMapWithWeaklyTypedInput(t *testing.T) {
+	src := map[string]interface{}{
+		`port`:    `8080`,
+		`ratio`:   `0.5`,
+		`debug`:   `true`,
+		`timeout`: `1m`,
+		`hosts`:   `localhost`,
+		`retries`: 3.0,
+	}
+	cfg := mapTestTyped{}
+	if err := Map(&cfg, src); err == nil {
+		t.Fatal(`expected a type mismatch without WithWeaklyTypedInput`)
+	}
+
+	cfg = mapTestTyped{}
+	if err := Map(&cfg, src, WithWeaklyTypedInput); err != nil {
+		t.Fatal(`error running Map: ` + err.Error())
+	}
+	retries := 3
+	want := mapTestTyped{8080, 0.5, true, time.Minute, []string{`localhost`}, &retries}
+	if !reflect.DeepEqual(cfg, want) {
+		spew.Dump(cfg)
+		t.Fatal(`Map did not coerce the values`)
+	}
+
+	if err := Map(&cfg, map[string]interface{}{`port`: 80.5}, WithWeaklyTypedInput); err == nil {
+		t.Fatal(`expected a lossy float to be rejected`)
+	}
+}
+
+func TestMapYAMLDocument(t *testing.T) {
+	doc := `
+name: svc
+primary:
+  host: a
+  port: 80
+`
+	var src map[interface{}]interface{}
+	if err := yaml.Unmarshal([]byte(doc), &src); err != nil {
+		t.Fatal(`error decoding YAML: ` + err.Error())
+	}
+	cfg := mapTestNested{}
+	if err := Map(&cfg, src); err != nil {
+		t.Fatal(`error running Map: ` + err.Error())
+	}
+	if cfg.Name != `svc` || cfg.Primary == nil || *cfg.Primary != (mapTestServer{`a`, 80}) {
+		spew.Dump(cfg)
+		t.Fatal(`Map did not handle a map[interface{}]interface{} source`)
+	}
+
+	type flat struct {
+		Labels map[string]interface{}
+	}
+	f := flat{}
+	labels := map[interface{}]interface{}{`labels`: map[interface{}]interface{}{`team`: `core`, 1: []interface{}{map[interface{}]interface{}{`a`: 1}}}}
+	if err := Map(&f, labels); err != nil {
+		t.Fatal(`error running Map: ` + err.Error())
+	}
+	want := map[string]interface{}{`team`: `core`, `1`: []interface{}{map[string]interface{}{`a`: 1}}}
+	if !reflect.DeepEqual(f.Labels, want) {
+		spew.Dump(f)
+		t.Fatal(`Map did not convert nested interface-keyed maps`)
+	}
+}
+
+func TestMapIntoTypedMap(t *testing.T) {
+	src := mapTestConfig{URL: `http://localhost`, Timeout: 5, Region: `us-east`}
+	dst := map[string]string{}
+	if err := Map(&dst, src, WithTagName(`json`)); err == nil {
+		t.Fatal(`expected a type mismatch for the non-string fields`)
+	}
+
+	dst = map[string]string{}
+	if err := Map(&dst, mapTestServer{`a`, 80}, WithWeaklyTypedInput); err != nil {
+		t.Fatal(`error running Map: ` + err.Error())
+	}
+	if !reflect.DeepEqual(dst, map[string]string{`host`: `a`, `port`: `80`}) {
+		spew.Dump(dst)
+		t.Fatal(`Map did not fill a map[string]string`)
+	}
+
+	cfg := mapTestServer{}
+	if err := Map(&cfg, map[string]string{`host`: `b`}); err != nil {
+		t.Fatal(`error running Map: ` + err.Error())
+	}
+	if cfg.Host != `b` {
+		spew.Dump(cfg)
+		t.Fatal(`Map did not read a map[string]string`)
+	}
+}
+
+type mapTestDocument struct {
+	Name     string
+	Primary  *mapTestServer
+	Servers  []mapTestServer
+	Backups  []*mapTestServer
+	ByName   map[string]mapTestServer
+	ByRegion map[string]*mapTestServer
+	Retries  *int
+}
+
+func TestMapCollectionsOfStructs(t *testing.T) {
+	doc := `
+name: svc
+primary:
+  host: a
+servers:
+  - host: b
+    port: 1
+  - host: c
+    prot: 2
+backups:
+  - host: d
+byName:
+  e:
+    host: e
+byRegion:
+  us:
+    port: 443
+retries: 5
+`
+	var src map[interface{}]interface{}
+	if err := yaml.Unmarshal([]byte(doc), &src); err != nil {
+		t.Fatal(`error decoding YAML: ` + err.Error())
+	}
+	cfg := mapTestDocument{
+		ByName:   map[string]mapTestServer{`f`: {Host: `f`}},
+		ByRegion: map[string]*mapTestServer{`us`: {Host: `us-host`}},
+	}
+	result, err := MapWithResult(&cfg, src)
+	if err != nil {
+		t.Fatal(`error running Map: ` + err.Error())
+	}
+	retries := 5
+	want := mapTestDocument{
+		Name:     `svc`,
+		Primary:  &mapTestServer{Host: `a`},
+		Servers:  []mapTestServer{{`b`, 1}, {Host: `c`}},
+		Backups:  []*mapTestServer{{Host: `d`}},
+		ByName:   map[string]mapTestServer{`e`: {Host: `e`}, `f`: {Host: `f`}},
+		ByRegion: map[string]*mapTestServer{`us`: {`us-host`, 443}},
+		Retries:  &retries,
+	}
+	if !reflect.DeepEqual(cfg, want) {
+		spew.Dump(cfg)
+		t.Fatal(`Map did not map the document into collections of structs`)
+	}
+	if !reflect.DeepEqual(result.UnknownKeys, []string{`servers[1].prot`}) {
+		spew.Dump(result)
+		t.Fatal(`Map did not report unknown keys inside slices`)
+	}
+}
@@ -12,7 +12,6 @@ import (
 	"fmt"
 	"os"
 	"reflect"
-	"strconv"
 	"strings"
 )
 
@@ -52,17 +51,46 @@ type Config struct {
 	overwriteWithEmptyValue      bool
 	overwriteSliceWithEmptyValue bool
 	sliceDeepCopy                bool
+	deepCopy                     bool
+	tagName                      string
+	nestedMaps                   bool
+	keyMatcher                   KeyMatcher
+	strictKeys                   bool
+	weaklyTypedInput             bool
 	debug                        bool
+	conflictResolver             ConflictResolver
+	emptyValueFunc               EmptyValueFunc
+	defaults                     bool
+}
+
+// walkState is the state of a single Merge or Map call as it walks its values.
+type walkState struct {
+	visited map[uintptr]*visit
+	// fieldPath is the dotted path of the struct field being merged, and field its
+	// FieldInfo, nil for anything but a struct field, to look up transformers
+	fieldPath string
+	field     *FieldInfo
+	// mapPath is the dotted path of the nested map being mapped into a struct
+	mapPath     string
+	unknownKeys []string
+}
+
+func newWalkState() *walkState {
+	return &walkState{visited: make(map[uintptr]*visit)}
 }
 
 type Transformers interface {
 	Transformer(reflect.Type) func(dst, src reflect.Value) error
 }
 
+// EmptyValueFunc reports whether v is empty. It returns ok false to leave the decision
+// to the default rules.
+type EmptyValueFunc func(v reflect.Value) (empty, ok bool)
+
 // Traverses recursively both values, assigning src's fields values to dst.
-// The map argument tracks comparisons that have already been seen, which allows
+// walk tracks the comparisons that have already been seen, which allows
 // short circuiting on recursive types.
-func deepMerge(dst, src reflect.Value, visited map[uintptr]*visit, depth int, config *Config) (err error) {
+func deepMerge(dst, src reflect.Value, walk *walkState, depth int, config *Config) (err error) {
 	overwrite := config.Overwrite
 	typeCheck := config.TypeCheck
 	overwriteWithEmptySrc := config.overwriteWithEmptyValue
@@ -75,7 +103,7 @@ func deepMerge(dst, src reflect.Value, visited map[uintptr]*visit, depth int, co
 	if dst.CanAddr() {
 		addr := dst.UnsafeAddr()
 		h := 17 * addr
-		seen := visited[h]
+		seen := walk.visited[h]
 		typ := dst.Type()
 		for p := seen; p != nil; p = p.next {
 			if p.ptr == addr && p.typ == typ {
@@ -83,10 +111,19 @@ func deepMerge(dst, src reflect.Value, visited map[uintptr]*visit, depth int, co
 			}
 		}
 		// Remember, remember...
-		visited[h] = &visit{addr, typ, seen}
+		walk.visited[h] = &visit{addr, typ, seen}
 	}
 
-	if config.Transformers != nil && !isEmptyValue(dst) {
+	field := walk.field
+	walk.field = nil
+	if registry, ok := config.Transformers.(*TransformerRegistry); ok {
+		// dst is invalid for a key of a src map that dst doesn't have
+		if entry := registry.lookup(dst, field, walk.fieldPath); entry != nil && (entry.Empty || !isEmpty(dst, config)) {
+			if err = entry.fn(dst, src, config.mergeSettings()); err != nil || !entry.ThenMerge {
+				return
+			}
+		}
+	} else if config.Transformers != nil && !isEmpty(dst, config) {
 		if fn := config.Transformers.Transformer(dst.Type()); fn != nil {
 			err = fn(dst, src)
 			return
@@ -135,15 +172,22 @@ func deepMerge(dst, src reflect.Value, visited map[uintptr]*visit, depth int, co
 					}
 					// TODO: PREVENT THIS IF WE GET THE VALUE FROM THE ENVIRONMENT:
 					if !overridden {
-						if err = deepMerge(dst.Field(i), src.Field(i), visited, depth+1, config); err != nil {
+						parentPath := walk.fieldPath
+						if !df.Anonymous {
+							walk.fieldPath = joinFieldPath(parentPath, df.Name)
+							walk.field = &dfi
+						}
+						err = deepMerge(dst.Field(i), src.Field(i), walk, depth+1, config)
+						walk.fieldPath, walk.field = parentPath, nil
+						if err != nil {
 							return
 						}
 					}
 				}
 			}
 		} else {
-			if dst.CanSet() && (isReflectNil(dst) || overwrite) && (!isEmptyValue(src) || overwriteWithEmptySrc) {
-				dst.Set(src)
+			if dst.CanSet() && (isEmpty(dst, config) || overwrite) && (!isEmpty(src, config) || overwriteWithEmptySrc) {
+				dst.Set(copyValue(src, config))
 			}
 		}
 	case reflect.Map:
@@ -158,7 +202,7 @@ func deepMerge(dst, src reflect.Value, visited map[uintptr]*visit, depth int, co
 
 		if src.Kind() != reflect.Map {
 			if overwrite {
-				dst.Set(src)
+				dst.Set(copyValue(src, config))
 			}
 			return
 		}
@@ -196,7 +240,7 @@ func deepMerge(dst, src reflect.Value, visited map[uintptr]*visit, depth int, co
 							dstMapElm = reflect.ValueOf(dstMapElm.Interface())
 						}
 					}
-					if err = deepMerge(dstMapElm, srcMapElm, visited, depth+1, config); err != nil {
+					if err = deepMerge(dstMapElm, srcMapElm, walk, depth+1, config); err != nil {
 						return
 					}
 				case reflect.Slice:
@@ -209,16 +253,16 @@ func deepMerge(dst, src reflect.Value, visited map[uintptr]*visit, depth int, co
 						dstSlice = reflect.ValueOf(dstElement.Interface())
 					}
 
-					if (!isEmptyValue(src) || overwriteWithEmptySrc || overwriteSliceWithEmptySrc) && (overwrite || isEmptyValue(dst)) && !config.AppendSlice && !sliceDeepCopy {
+					if (!isEmpty(src, config) || overwriteWithEmptySrc || overwriteSliceWithEmptySrc) && (overwrite || isEmpty(dst, config)) && !config.AppendSlice && !sliceDeepCopy {
 						if typeCheck && srcSlice.Type() != dstSlice.Type() {
 							return fmt.Errorf("cannot override two slices with different type (%s, %s)", srcSlice.Type(), dstSlice.Type())
 						}
-						dstSlice = srcSlice
+						dstSlice = copyValue(srcSlice, config)
 					} else if config.AppendSlice {
 						if srcSlice.Type() != dstSlice.Type() {
 							return fmt.Errorf("cannot append two slices with different type (%s, %s)", srcSlice.Type(), dstSlice.Type())
 						}
-						dstSlice = reflect.AppendSlice(dstSlice, srcSlice)
+						dstSlice = reflect.AppendSlice(dstSlice, copyValue(srcSlice, config))
 					} else if sliceDeepCopy {
 						i := 0
 						for ; i < srcSlice.Len() && i < dstSlice.Len(); i++ {
@@ -232,7 +276,7 @@ func deepMerge(dst, src reflect.Value, visited map[uintptr]*visit, depth int, co
 								dstElement = reflect.ValueOf(dstElement.Interface())
 							}
 
-							if err = deepMerge(dstElement, srcElement, visited, depth+1, config); err != nil {
+							if err = deepMerge(dstElement, srcElement, walk, depth+1, config); err != nil {
 								return
 							}
 						}
@@ -241,28 +285,28 @@ func deepMerge(dst, src reflect.Value, visited map[uintptr]*visit, depth int, co
 					dst.SetMapIndex(key, dstSlice)
 				}
 			}
-			if dstElement.IsValid() && !isEmptyValue(dstElement) && (reflect.TypeOf(srcElement.Interface()).Kind() == reflect.Map || reflect.TypeOf(srcElement.Interface()).Kind() == reflect.Slice) {
+			if dstElement.IsValid() && !isEmpty(dstElement, config) && (reflect.TypeOf(srcElement.Interface()).Kind() == reflect.Map || reflect.TypeOf(srcElement.Interface()).Kind() == reflect.Slice) {
 				continue
 			}
 
-			if srcElement.IsValid() && ((srcElement.Kind() != reflect.Ptr && overwrite) || !dstElement.IsValid() || isEmptyValue(dstElement)) {
+			if srcElement.IsValid() && ((srcElement.Kind() != reflect.Ptr && overwrite) || !dstElement.IsValid() || isEmpty(dstElement, config)) {
 				if dst.IsNil() {
 					dst.Set(reflect.MakeMap(dst.Type()))
 				}
-				dst.SetMapIndex(key, srcElement)
+				dst.SetMapIndex(key, copyValue(srcElement, config))
 			}
 		}
 	case reflect.Slice:
 		if !dst.CanSet() {
 			break
 		}
-		if (!isEmptyValue(src) || overwriteWithEmptySrc || overwriteSliceWithEmptySrc) && (overwrite || isEmptyValue(dst)) && !config.AppendSlice && !sliceDeepCopy {
-			dst.Set(src)
+		if (!isEmpty(src, config) || overwriteWithEmptySrc || overwriteSliceWithEmptySrc) && (overwrite || isEmpty(dst, config)) && !config.AppendSlice && !sliceDeepCopy {
+			dst.Set(copyValue(src, config))
 		} else if config.AppendSlice {
 			if src.Type() != dst.Type() {
 				return fmt.Errorf("cannot append two slice with different type (%s, %s)", src.Type(), dst.Type())
 			}
-			dst.Set(reflect.AppendSlice(dst, src))
+			dst.Set(reflect.AppendSlice(dst, copyValue(src, config)))
 		} else if sliceDeepCopy {
 			for i := 0; i < src.Len() && i < dst.Len(); i++ {
 				srcElement := src.Index(i)
@@ -274,7 +318,7 @@ func deepMerge(dst, src reflect.Value, visited map[uintptr]*visit, depth int, co
 					dstElement = reflect.ValueOf(dstElement.Interface())
 				}
 
-				if err = deepMerge(dstElement, srcElement, visited, depth+1, config); err != nil {
+				if err = deepMerge(dstElement, srcElement, walk, depth+1, config); err != nil {
 					return
 				}
 			}
@@ -291,15 +335,15 @@ func deepMerge(dst, src reflect.Value, visited map[uintptr]*visit, depth int, co
 
 		if src.Kind() != reflect.Interface {
 			if dst.IsNil() || (src.Kind() != reflect.Ptr && overwrite) {
-				if dst.CanSet() && (overwrite || isEmptyValue(dst)) {
-					dst.Set(src)
+				if dst.CanSet() && (overwrite || isEmpty(dst, config)) {
+					dst.Set(copyValue(src, config))
 				}
 			} else if src.Kind() == reflect.Ptr {
-				if err = deepMerge(dst.Elem(), src.Elem(), visited, depth+1, config); err != nil {
+				if err = deepMerge(dst.Elem(), src.Elem(), walk, depth+1, config); err != nil {
 					return
 				}
 			} else if dst.Elem().Type() == src.Type() {
-				if err = deepMerge(dst.Elem(), src, visited, depth+1, config); err != nil {
+				if err = deepMerge(dst.Elem(), src, walk, depth+1, config); err != nil {
 					return
 				}
 			} else {
@@ -309,23 +353,23 @@ func deepMerge(dst, src reflect.Value, visited map[uintptr]*visit, depth int, co
 		}
 
 		if dst.IsNil() || overwrite {
-			if dst.CanSet() && (overwrite || isEmptyValue(dst)) {
-				dst.Set(src)
+			if dst.CanSet() && (overwrite || isEmpty(dst, config)) {
+				dst.Set(copyValue(src, config))
 			}
 			break
 		}
 
 		if dst.Elem().Kind() == src.Elem().Kind() {
-			if err = deepMerge(dst.Elem(), src.Elem(), visited, depth+1, config); err != nil {
+			if err = deepMerge(dst.Elem(), src.Elem(), walk, depth+1, config); err != nil {
 				return
 			}
 			break
 		}
 	default:
-		mustSet := (isEmptyValue(dst) || overwrite) && (!isEmptyValue(src) || overwriteWithEmptySrc)
+		mustSet := (isEmpty(dst, config) || overwrite) && (!isEmpty(src, config) || overwriteWithEmptySrc)
 		if mustSet {
 			if dst.CanSet() {
-				dst.Set(src)
+				dst.Set(copyValue(src, config))
 			} else {
 				dst = src
 			}
@@ -351,6 +395,7 @@ func MergeWithOverwrite(dst, src interface{}, opts ...func(*Config)) error {
 }
 
 // WithTransformers adds transformers to merge, allowing to customize the merging of some types.
+// A *TransformerRegistry also customizes the merging of fields by path and tag.
 func WithTransformers(transformers Transformers) func(*Config) {
 	return func(config *Config) {
 		config.Transformers = transformers
@@ -389,6 +434,54 @@ func WithSliceDeepCopy(config *Config) {
 	config.Overwrite = true
 }
 
+// WithTagName will make Map name keys after the given struct tag (e.g. "json" or "yaml") instead of the field names.
+func WithTagName(tagName string) func(*Config) {
+	return func(config *Config) {
+		config.tagName = tagName
+	}
+}
+
+// WithNestedMaps will make Map convert nested structs, pointers to structs and slices of structs into maps and slices of maps.
+func WithNestedMaps(config *Config) {
+	config.nestedMaps = true
+}
+
+// WithKeyMatcher will make Map match keys to struct fields with matcher instead of the default rules,
+// which only ignore the case of the first letter; use ExactKeyMatch to match keys as they are.
+func WithKeyMatcher(matcher KeyMatcher) func(*Config) {
+	return func(config *Config) {
+		config.keyMatcher = matcher
+	}
+}
+
+// WithStrictKeys will make Map fail with an *UnknownKeysError listing the src keys that match no field in dst.
+func WithStrictKeys(config *Config) {
+	config.strictKeys = true
+}
+
+// WithWeaklyTypedInput will make Map convert src values whose type does not match the field, e.g. "8080" or 8080.0 into an int.
+func WithWeaklyTypedInput(config *Config) {
+	config.weaklyTypedInput = true
+}
+
+// WithEmptyValueFunc will make merge ask fn whether a value is empty before the default rules,
+// which honor an IsZero method and otherwise compare with the zero value of its kind.
+func WithEmptyValueFunc(fn EmptyValueFunc) func(*Config) {
+	return func(config *Config) {
+		config.emptyValueFunc = fn
+	}
+}
+
+// WithDefaults will make merge fill the fields of dst still empty afterwards from their `default` tags, as ApplyDefaults does.
+func WithDefaults(config *Config) {
+	config.defaults = true
+}
+
+// WithDeepCopy will make merge copy pointers, maps and slices taken from src instead of sharing them with dst.
+func WithDeepCopy(config *Config) {
+	config.deepCopy = true
+}
+
 func merge(dst, src interface{}, opts ...func(*Config)) error {
 	if dst != nil && reflect.ValueOf(dst).Kind() != reflect.Ptr {
 		return ErrNonPointerAgument
@@ -410,7 +503,13 @@ func merge(dst, src interface{}, opts ...func(*Config)) error {
 	if vDst.Type() != vSrc.Type() {
 		return ErrDifferentArgumentsTypes
 	}
-	return deepMerge(vDst, vSrc, make(map[uintptr]*visit), 0, config)
+	if err = deepMerge(vDst, vSrc, newWalkState(), 0, config); err != nil {
+		return err
+	}
+	if config.defaults {
+		return applyDefaults(vDst, ``, config)
+	}
+	return nil
 }
 
 // IsReflectNil is the reflect value provided nil
@@ -431,6 +530,10 @@ const (
 	FieldTagOptional     string = `optional`
 	FieldTagFinal        string = `final`
 	FieldTagMustOverride string = `mustoverride`
+	FieldTagSecret       string = `secret`
+	FieldTagFlag         string = `flag`
+	FieldTagUsage        string = `usage`
+	FieldTagDefault      string = `default`
 )
 
 // parseField inspects the metadata for a struct field and returns relevant values
@@ -444,17 +547,34 @@ func parseField(f reflect.StructField) FieldInfo {
 
 	switch rtn.Kind {
 	case reflect.Struct, reflect.Map, reflect.Array, reflect.Slice:
-		rtn.Complex = true
+		// an Opt holds a single value, which can come from the environment
+		rtn.Complex = !isOptionalType(f.Type)
 	default:
 		rtn.Complex = false
 	}
 
 	if v, ok := f.Tag.Lookup(FieldTagName); ok {
 		rtn.Tags = strings.Split(v, ",")
-		rtn.Optional = strings.Contains(v, FieldTagOptional)
-		rtn.Final = strings.Contains(v, FieldTagFinal)
-		rtn.Mustoverride = strings.Contains(v, FieldTagMustOverride)
+		// match whole elements, so that e.g. flag=finalize does not make the field final
+		for _, tag := range rtn.Tags {
+			tag = strings.TrimSpace(tag)
+			switch tag {
+			case FieldTagOptional:
+				rtn.Optional = true
+			case FieldTagFinal:
+				rtn.Final = true
+			case FieldTagMustOverride:
+				rtn.Mustoverride = true
+			case FieldTagSecret:
+				rtn.Secret = true
+			}
+			if strings.HasPrefix(tag, FieldTagFlag+`=`) {
+				rtn.Flag = strings.TrimPrefix(tag, FieldTagFlag+`=`)
+			}
+		}
 	}
+	rtn.Usage = f.Tag.Get(FieldTagUsage)
+	rtn.Default = f.Tag.Get(FieldTagDefault)
 
 	return rtn
 }
@@ -469,6 +589,10 @@ type FieldInfo struct {
 	Final        bool
 	Complex      bool
 	Mustoverride bool
+	Secret       bool
+	Flag         string
+	Usage        string
+	Default      string
 }
 
 // valueFromEnvironment checks the submitted environment variable name for a value
@@ -484,6 +608,19 @@ func valueFromEnvironment(fieldValue reflect.Value, envVarName string) bool {
 	// var z reflect.Value
 	fieldType := fieldValue.Type()
 
+	if opt, ok := asOptional(fieldValue); ok {
+		if envVal := getEnvironmentString(envVarName); envVal != nil {
+			if value, err := parseValue(*envVal, opt.optionalType()); err == nil {
+				opt.setOptional(value)
+				return true
+			}
+		}
+		if capECName := strings.ToUpper(envVarName); capECName != envVarName {
+			return valueFromEnvironment(fieldValue, capECName)
+		}
+		return false
+	}
+
 	switch fieldType.Kind() {
 	case reflect.Ptr:
 		// this field is a pointer
@@ -607,8 +744,9 @@ func getEnvironmentString(vn string) *string {
 func getEnvironmentInt(vn string) *int {
 	var rtn *int
 	if strvalue := os.Getenv(vn); strvalue != "" {
-		if value, err := strconv.Atoi(strvalue); err == nil {
-			rtn = &value
+		if value, err := parseValue(strvalue, reflect.TypeOf(0)); err == nil {
+			i := int(value.Int())
+			rtn = &i
 		}
 	}
 	return rtn
@@ -617,8 +755,9 @@ func getEnvironmentInt(vn string) *int {
 func getEnvironmentBool(vn string) *bool {
 	var rtn *bool
 	if strvalue := os.Getenv(vn); strvalue != "" {
-		if value, err := strconv.ParseBool(strvalue); err == nil {
-			rtn = &value
+		if value, err := parseValue(strvalue, reflect.TypeOf(false)); err == nil {
+			b := value.Bool()
+			rtn = &b
 		}
 	}
 	return rtn
@@ -627,8 +766,9 @@ func getEnvironmentBool(vn string) *bool {
 func getEnvironmentFloat64(vn string) *float64 {
 	var rtn *float64
 	if strvalue := os.Getenv(vn); strvalue != "" {
-		if value, err := strconv.ParseFloat(strvalue, 64); err == nil {
-			rtn = &value
+		if value, err := parseValue(strvalue, reflect.TypeOf(float64(0))); err == nil {
+			f := value.Float()
+			rtn = &f
 		}
 	}
 	return rtn
@@ -0,0 +1,235 @@
+package mergo
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Conflict is a path changed differently by both sides of a Merge3.
+// A nil Ours or Theirs for a map entry means that side removed the key.
+type Conflict struct {
+	Path   string
+	Base   interface{}
+	Ours   interface{}
+	Theirs interface{}
+}
+
+func (c Conflict) String() string {
+	return fmt.Sprintf("conflict at %s: base %v, ours %v, theirs %v", c.Path, c.Base, c.Ours, c.Theirs)
+}
+
+// ConflictResolver decides a Conflict found by Merge3. It returns the value to keep
+// and true, or false to leave ours untouched and report the conflict.
+// Resolving a map entry to nil removes the key.
+type ConflictResolver func(c Conflict) (value interface{}, resolved bool)
+
+// ResolveOurs is a ConflictResolver keeping our side of every conflict.
+func ResolveOurs(c Conflict) (interface{}, bool) {
+	return c.Ours, true
+}
+
+// ResolveTheirs is a ConflictResolver taking their side of every conflict.
+func ResolveTheirs(c Conflict) (interface{}, bool) {
+	return c.Theirs, true
+}
+
+// WithConflictResolver makes Merge3 consult resolver for each conflict before reporting it.
+func WithConflictResolver(resolver ConflictResolver) func(*Config) {
+	return func(config *Config) {
+		config.conflictResolver = resolver
+	}
+}
+
+// Merge3 performs a three-way merge: the changes theirs made to base are applied to ours,
+// which must be a pointer, unless ours changed the same path differently. Those paths
+// are left as they are in ours and returned as conflicts, after giving the resolver set
+// with WithConflictResolver a chance to settle them.
+// Structs are merged field by field and maps key by key; slices and other values are
+// compared as a whole. Final fields are never changed.
+func Merge3(base, ours, theirs interface{}, opts ...func(*Config)) ([]Conflict, error) {
+	if base == nil || ours == nil || theirs == nil {
+		return nil, ErrNilArguments
+	}
+	vOurs := reflect.ValueOf(ours)
+	if vOurs.Kind() != reflect.Ptr {
+		return nil, ErrNonPointerAgument
+	}
+	if vOurs.IsNil() {
+		return nil, ErrNilArguments
+	}
+	vOurs = vOurs.Elem()
+	if vOurs.Kind() != reflect.Struct && vOurs.Kind() != reflect.Map {
+		return nil, ErrNotSupported
+	}
+	vBase, vTheirs := reflect.ValueOf(base), reflect.ValueOf(theirs)
+	if vBase.Kind() == reflect.Ptr {
+		vBase = vBase.Elem()
+	}
+	if vTheirs.Kind() == reflect.Ptr {
+		vTheirs = vTheirs.Elem()
+	}
+	if !vBase.IsValid() || !vTheirs.IsValid() {
+		return nil, ErrNilArguments
+	}
+	if vBase.Type() != vOurs.Type() || vTheirs.Type() != vOurs.Type() {
+		return nil, ErrDifferentArgumentsTypes
+	}
+
+	config := &Config{}
+	for _, opt := range opts {
+		opt(config)
+	}
+	m := &merger3{config: config}
+	if err := m.merge(vOurs, vBase, vTheirs, ``); err != nil {
+		return m.conflicts, err
+	}
+	return m.conflicts, nil
+}
+
+type merger3 struct {
+	config    *Config
+	conflicts []Conflict
+}
+
+func sameValue(a, b reflect.Value) bool {
+	if !a.IsValid() || !b.IsValid() {
+		return a.IsValid() == b.IsValid()
+	}
+	return reflect.DeepEqual(a.Interface(), b.Interface())
+}
+
+// merge applies the change from base to theirs onto ours, which must be settable.
+func (m *merger3) merge(ours, base, theirs reflect.Value, path string) error {
+	if ours.Kind() == reflect.Struct && hasMergeableFields(ours) {
+		for i, n := 0, ours.NumField(); i < n; i++ {
+			sf := ours.Type().Field(i)
+			if sf.Anonymous && sf.Type.Kind() == reflect.Struct {
+				// an embedded struct of an unexported type is only reachable through
+				// its exported fields
+				if !ours.Field(i).CanInterface() && !hasMergeableFields(ours.Field(i)) {
+					continue
+				}
+				if err := m.merge(ours.Field(i), base.Field(i), theirs.Field(i), path); err != nil {
+					return err
+				}
+				continue
+			}
+			if !isExportedComponent(&sf) || parseField(sf).Final {
+				continue
+			}
+			if err := m.merge(ours.Field(i), base.Field(i), theirs.Field(i), joinFieldPath(path, sf.Name)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if sameValue(base, theirs) || sameValue(ours, theirs) {
+		return nil
+	}
+
+	// pointers, interfaces and maps are walked even when ours did not change them, so
+	// that the final fields inside are kept; ours may share them with base, so they
+	// are copied first
+	switch ours.Kind() {
+	case reflect.Ptr:
+		if !ours.IsNil() && !base.IsNil() && !theirs.IsNil() {
+			cp := reflect.New(ours.Type().Elem())
+			cp.Elem().Set(ours.Elem())
+			if err := m.merge(cp.Elem(), base.Elem(), theirs.Elem(), path); err != nil {
+				return err
+			}
+			ours.Set(cp)
+			return nil
+		}
+	case reflect.Interface:
+		if !ours.IsNil() && !base.IsNil() && !theirs.IsNil() &&
+			ours.Elem().Type() == base.Elem().Type() && base.Elem().Type() == theirs.Elem().Type() {
+			cp := reflect.New(ours.Elem().Type()).Elem()
+			cp.Set(ours.Elem())
+			if err := m.merge(cp, base.Elem(), theirs.Elem(), path); err != nil {
+				return err
+			}
+			ours.Set(cp)
+			return nil
+		}
+	case reflect.Map:
+		if !ours.IsNil() && !base.IsNil() && !theirs.IsNil() {
+			cp := reflect.MakeMapWithSize(ours.Type(), ours.Len())
+			for _, k := range ours.MapKeys() {
+				cp.SetMapIndex(k, ours.MapIndex(k))
+			}
+			if err := m.mergeMap(cp, base, theirs, path); err != nil {
+				return err
+			}
+			ours.Set(cp)
+			return nil
+		}
+	}
+	if sameValue(base, ours) {
+		ours.Set(theirs)
+		return nil
+	}
+	return m.conflict(path, ours.Type(), base, ours, theirs, func(v reflect.Value) {
+		if !v.IsValid() {
+			v = reflect.Zero(ours.Type())
+		}
+		ours.Set(v)
+	})
+}
+
+func (m *merger3) mergeMap(ours, base, theirs reflect.Value, path string) error {
+	for _, key := range unionMapKeys(base, ours, theirs) {
+		b, o, t := base.MapIndex(key), ours.MapIndex(key), theirs.MapIndex(key)
+		kp := joinIndexPath(path, key.Interface())
+		if sameValue(b, t) || sameValue(o, t) {
+			continue
+		}
+		if b.IsValid() && o.IsValid() && t.IsValid() {
+			cp := reflect.New(o.Type()).Elem()
+			cp.Set(o)
+			if err := m.merge(cp, b, t, kp); err != nil {
+				return err
+			}
+			ours.SetMapIndex(key, cp)
+			continue
+		}
+		if sameValue(b, o) {
+			ours.SetMapIndex(key, t)
+			continue
+		}
+		key := key
+		if err := m.conflict(kp, ours.Type().Elem(), b, o, t, func(v reflect.Value) {
+			ours.SetMapIndex(key, v)
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// conflict records a conflict, or applies the resolver's decision through set.
+// set receives an invalid Value when the resolver chose nil.
+func (m *merger3) conflict(path string, typ reflect.Type, base, ours, theirs reflect.Value, set func(reflect.Value)) error {
+	iface := func(v reflect.Value) interface{} {
+		if !v.IsValid() {
+			return nil
+		}
+		return v.Interface()
+	}
+	c := Conflict{Path: path, Base: iface(base), Ours: iface(ours), Theirs: iface(theirs)}
+	if m.config.conflictResolver != nil {
+		if value, ok := m.config.conflictResolver(c); ok {
+			v := reflect.ValueOf(value)
+			if v.IsValid() && !v.Type().AssignableTo(typ) {
+				if !v.Type().ConvertibleTo(typ) {
+					return fmt.Errorf("cannot resolve conflict at %s with %s, expected %s", path, v.Type(), typ)
+				}
+				v = v.Convert(typ)
+			}
+			set(v)
+			return nil
+		}
+	}
+	m.conflicts = append(m.conflicts, c)
+	return nil
+}
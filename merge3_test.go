@@ -0,0 +1,175 @@
+package mergo
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/davecgh/go-spew/spew"
+)
+
+type merge3TestConfig struct {
+	RequiredConfig
+	Replicas int
+	Hosts    []string
+	Labels   map[string]string
+	Limits   *merge3TestLimits
+}
+
+type merge3TestLimits struct {
+	CPU    string
+	Memory string
+}
+
+func newMerge3TestConfig() merge3TestConfig {
+	return merge3TestConfig{
+		RequiredConfig: RequiredConfig{OverrideConfigPath: `/etc/base.yml`, Environment: `dev`, LogLevel: `info`},
+		Replicas:       1,
+		Hosts:          []string{`a`},
+		Labels:         map[string]string{`team`: `core`, `tier`: `web`},
+		Limits:         &merge3TestLimits{`1`, `1Gi`},
+	}
+}
+
+func TestMerge3(t *testing.T) {
+	base := newMerge3TestConfig()
+	ours := newMerge3TestConfig()
+	theirs := newMerge3TestConfig()
+
+	ours.LogLevel = `debug`
+	ours.Replicas = 2
+	ours.Labels[`owner`] = `us`
+	ours.Limits.CPU = `2`
+
+	theirs.Environment = `prod`
+	theirs.OverrideConfigPath = `/etc/theirs.yml`
+	theirs.Replicas = 3
+	delete(theirs.Labels, `tier`)
+	theirs.Limits.Memory = `2Gi`
+	theirs.Hosts = []string{`a`, `b`}
+
+	conflicts, err := Merge3(base, &ours, theirs)
+	if err != nil {
+		t.Fatal(`error running Merge3: ` + err.Error())
+	}
+	want := merge3TestConfig{
+		RequiredConfig: RequiredConfig{OverrideConfigPath: `/etc/base.yml`, Environment: `prod`, LogLevel: `debug`},
+		Replicas:       2,
+		Hosts:          []string{`a`, `b`},
+		Labels:         map[string]string{`team`: `core`, `owner`: `us`},
+		Limits:         &merge3TestLimits{`2`, `2Gi`},
+	}
+	if !reflect.DeepEqual(ours, want) {
+		spew.Dump(ours)
+		t.Fatal(`Merge3 did not apply the non-conflicting changes`)
+	}
+	wantConflicts := []Conflict{{Path: `Replicas`, Base: 1, Ours: 2, Theirs: 3}}
+	if !reflect.DeepEqual(conflicts, wantConflicts) {
+		spew.Dump(conflicts)
+		t.Fatal(`Merge3 did not report the expected conflicts`)
+	}
+}
+
+func TestMerge3WithConflictResolver(t *testing.T) {
+	base := newMerge3TestConfig()
+	ours := newMerge3TestConfig()
+	theirs := newMerge3TestConfig()
+
+	ours.Replicas = 2
+	ours.Labels[`team`] = `us`
+	theirs.Replicas = 3
+	delete(theirs.Labels, `team`)
+
+	conflicts, err := Merge3(&base, &ours, &theirs, WithConflictResolver(ResolveTheirs))
+	if err != nil {
+		t.Fatal(`error running Merge3: ` + err.Error())
+	}
+	if len(conflicts) != 0 {
+		spew.Dump(conflicts)
+		t.Fatal(`resolved conflicts should not be reported`)
+	}
+	if ours.Replicas != 3 || !reflect.DeepEqual(ours.Labels, map[string]string{`tier`: `web`}) {
+		spew.Dump(ours)
+		t.Fatal(`Merge3 did not apply the resolver's decisions`)
+	}
+
+	ours.Replicas = 4
+	resolver := func(c Conflict) (interface{}, bool) {
+		return c.Ours.(int) + c.Theirs.(int), true
+	}
+	if _, err = Merge3(&base, &ours, &theirs, WithConflictResolver(resolver)); err != nil {
+		t.Fatal(`error running Merge3: ` + err.Error())
+	}
+	if ours.Replicas != 7 {
+		t.Fatalf(`expected the custom resolution 7, got %d`, ours.Replicas)
+	}
+}
+
+type merge3TestHidden struct {
+	x int
+}
+
+type merge3TestHiddenEmbed struct {
+	merge3TestHidden
+	A int
+	B int
+}
+
+func TestMerge3HiddenEmbed(t *testing.T) {
+	base := merge3TestHiddenEmbed{merge3TestHidden{1}, 1, 1}
+	ours := merge3TestHiddenEmbed{merge3TestHidden{2}, 2, 1}
+	theirs := merge3TestHiddenEmbed{merge3TestHidden{3}, 1, 3}
+	conflicts, err := Merge3(base, &ours, theirs)
+	if err != nil || len(conflicts) != 0 {
+		t.Fatalf(`unexpected result %v, %v`, conflicts, err)
+	}
+	if want := (merge3TestHiddenEmbed{merge3TestHidden{2}, 2, 3}); ours != want {
+		t.Fatalf(`expected %+v, got %+v`, want, ours)
+	}
+}
+
+type merge3TestSecret struct {
+	Key   string `config:"final"`
+	Value string
+}
+
+type merge3TestFinalConfig struct {
+	DB      *merge3TestSecret
+	Secrets map[string]merge3TestSecret
+}
+
+func TestMerge3KeepsFinalFields(t *testing.T) {
+	base := merge3TestFinalConfig{
+		DB:      &merge3TestSecret{`k`, `v`},
+		Secrets: map[string]merge3TestSecret{`a`: {`k`, `v`}},
+	}
+	ours := base
+	theirs := merge3TestFinalConfig{
+		DB:      &merge3TestSecret{`other`, `w`},
+		Secrets: map[string]merge3TestSecret{`a`: {`other`, `w`}},
+	}
+	conflicts, err := Merge3(base, &ours, theirs)
+	if err != nil || len(conflicts) != 0 {
+		t.Fatalf(`unexpected result %v, %v`, conflicts, err)
+	}
+	want := merge3TestFinalConfig{
+		DB:      &merge3TestSecret{`k`, `w`},
+		Secrets: map[string]merge3TestSecret{`a`: {`k`, `w`}},
+	}
+	if !reflect.DeepEqual(ours, want) {
+		spew.Dump(ours)
+		t.Fatal(`Merge3 changed final fields`)
+	}
+	if base.DB.Value != `v` || base.Secrets[`a`].Value != `v` {
+		t.Fatal(`Merge3 modified base`)
+	}
+}
+
+func TestMerge3NilPointers(t *testing.T) {
+	ours := newMerge3TestConfig()
+	if _, err := Merge3((*merge3TestConfig)(nil), &ours, newMerge3TestConfig()); err != ErrNilArguments {
+		t.Fatalf(`expected %v, got %v`, ErrNilArguments, err)
+	}
+	if _, err := Merge3(newMerge3TestConfig(), &ours, (*merge3TestConfig)(nil)); err != ErrNilArguments {
+		t.Fatalf(`expected %v, got %v`, ErrNilArguments, err)
+	}
+}
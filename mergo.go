@@ -35,6 +35,20 @@ type visit struct {
 
 // From src/pkg/encoding/json/encode.go.
 func isEmptyValue(v reflect.Value) bool {
+	return isEmptyValueFunc(v, nil)
+}
+
+// isEmptyValueFunc reports whether v is empty, asking fn first when it is not nil,
+// then an IsZero method of v, then falling back to the zero value of its kind.
+func isEmptyValueFunc(v reflect.Value, fn EmptyValueFunc) bool {
+	if fn != nil && v.IsValid() {
+		if empty, ok := fn(v); ok {
+			return empty
+		}
+	}
+	if zero, ok := isZeroer(v); ok {
+		return zero
+	}
 	switch v.Kind() {
 	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
 		return v.Len() == 0
@@ -50,7 +64,7 @@ func isEmptyValue(v reflect.Value) bool {
 		if v.IsNil() {
 			return true
 		}
-		return isEmptyValue(v.Elem())
+		return isEmptyValueFunc(v.Elem(), fn)
 	case reflect.Func:
 		return v.IsNil()
 	case reflect.Invalid:
@@ -59,6 +73,48 @@ func isEmptyValue(v reflect.Value) bool {
 	return false
 }
 
+// isEmpty reports whether v is empty for the running merge.
+func isEmpty(v reflect.Value, config *Config) bool {
+	return isEmptyValueFunc(v, config.emptyValueFunc)
+}
+
+// Zeroer is implemented by values that tell whether they are empty, such as time.Time.
+// Merge treats a value whose IsZero method returns true as empty, like the omitzero
+// option of encoding/json.
+type Zeroer interface {
+	IsZero() bool
+}
+
+var zeroerType = reflect.TypeOf((*Zeroer)(nil)).Elem()
+
+// isZeroer calls the IsZero method of v, or of a pointer to it (or to a copy of it) when
+// the method has a pointer receiver. ok is false when v has no such method or is nil.
+func isZeroer(v reflect.Value) (zero, ok bool) {
+	switch v.Kind() {
+	case reflect.Invalid:
+		return false, false
+	case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice, reflect.Func, reflect.Chan:
+		if v.IsNil() {
+			return false, false
+		}
+	}
+	if !v.CanInterface() {
+		return false, false
+	}
+	if v.Kind() != reflect.Interface && v.Type().Implements(zeroerType) {
+		return v.Interface().(Zeroer).IsZero(), true
+	}
+	if reflect.PtrTo(v.Type()).Implements(zeroerType) {
+		if !v.CanAddr() {
+			p := reflect.New(v.Type())
+			p.Elem().Set(v)
+			v = p.Elem()
+		}
+		return v.Addr().Interface().(Zeroer).IsZero(), true
+	}
+	return false, false
+}
+
 func resolveValues(dst, src interface{}) (vDst, vSrc reflect.Value, err error) {
 	if dst == nil || src == nil {
 		err = ErrNilArguments
@@ -0,0 +1,146 @@
+package mergo
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// Opt holds an optional value of type T and records whether it was set, so that an
+// explicit zero value such as Retries: 0 or Enabled: false is not mistaken for a
+// missing one. Merge treats an unset Opt as empty and a set one as non-empty, whatever
+// its value, so a set src overrides dst WithOverride and fills an unset dst without it.
+// Map, environment overrides and JSON, YAML and TOML decoding set it from its value type.
+type Opt[T any] struct {
+	value T
+	set   bool
+}
+
+// Some returns an Opt set to v.
+func Some[T any](v T) Opt[T] {
+	return Opt[T]{value: v, set: true}
+}
+
+// Get returns the value of o, or the zero value of T if o is not set.
+func (o Opt[T]) Get() T {
+	return o.value
+}
+
+// GetOr returns the value of o, or def if o is not set.
+func (o Opt[T]) GetOr(def T) T {
+	if !o.set {
+		return def
+	}
+	return o.value
+}
+
+// IsSet reports whether o was set.
+func (o Opt[T]) IsSet() bool {
+	return o.set
+}
+
+// IsZero reports whether o is not set, making an unset Opt empty for Merge.
+func (o Opt[T]) IsZero() bool {
+	return !o.set
+}
+
+// Set sets o to v.
+func (o *Opt[T]) Set(v T) {
+	o.value, o.set = v, true
+}
+
+// Unset clears o.
+func (o *Opt[T]) Unset() {
+	*o = Opt[T]{}
+}
+
+// MarshalJSON encodes the value of o, or null if o is not set.
+func (o Opt[T]) MarshalJSON() ([]byte, error) {
+	if !o.set {
+		return []byte(`null`), nil
+	}
+	return json.Marshal(o.value)
+}
+
+// UnmarshalJSON sets o to the decoded value; null leaves o unset.
+func (o *Opt[T]) UnmarshalJSON(data []byte) error {
+	if string(data) == `null` {
+		o.Unset()
+		return nil
+	}
+	if err := json.Unmarshal(data, &o.value); err != nil {
+		return err
+	}
+	o.set = true
+	return nil
+}
+
+// MarshalYAML encodes the value of o, or null if o is not set.
+func (o Opt[T]) MarshalYAML() (interface{}, error) {
+	if !o.set {
+		return nil, nil
+	}
+	return o.value, nil
+}
+
+// UnmarshalYAML sets o to the decoded value; null leaves o unset.
+func (o *Opt[T]) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	if err := unmarshal(&o.value); err != nil {
+		return err
+	}
+	o.set = true
+	return nil
+}
+
+// UnmarshalTOML sets o to the decoded value, converted like WithWeaklyTypedInput does,
+// since TOML integers decode as int64.
+func (o *Opt[T]) UnmarshalTOML(data interface{}) error {
+	v, ok := coerceValue(reflect.ValueOf(data), o.optionalType())
+	if !ok {
+		return fmt.Errorf("cannot decode %v into %s", data, o.optionalType())
+	}
+	o.setOptional(v)
+	return nil
+}
+
+func (o *Opt[T]) optionalType() reflect.Type {
+	return reflect.TypeOf(&o.value).Elem()
+}
+
+func (o *Opt[T]) optionalValue() (reflect.Value, bool) {
+	return reflect.ValueOf(&o.value).Elem(), o.set
+}
+
+func (o *Opt[T]) setOptional(v reflect.Value) {
+	reflect.ValueOf(&o.value).Elem().Set(v)
+	o.set = true
+}
+
+// optional is implemented by *Opt[T], letting Map and the environment overrides read
+// and set the wrapped value without knowing T.
+type optional interface {
+	optionalType() reflect.Type
+	optionalValue() (reflect.Value, bool)
+	setOptional(v reflect.Value)
+}
+
+var optionalInterface = reflect.TypeOf((*optional)(nil)).Elem()
+
+// isOptionalType reports whether typ is an Opt.
+func isOptionalType(typ reflect.Type) bool {
+	return reflect.PtrTo(typ).Implements(optionalInterface)
+}
+
+// asOptional returns v as an optional, or false if v is not an Opt. Setting it only
+// changes v if v is addressable.
+func asOptional(v reflect.Value) (optional, bool) {
+	if !v.IsValid() || !v.CanInterface() || !isOptionalType(v.Type()) {
+		return nil, false
+	}
+	if !v.CanAddr() {
+		p := reflect.New(v.Type())
+		p.Elem().Set(v)
+		v = p.Elem()
+	}
+	return v.Addr().Interface().(optional), true
+}
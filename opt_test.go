@@ -0,0 +1,110 @@
+package mergo
+
+import (
+	"encoding/json"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/davecgh/go-spew/spew"
+	"gopkg.in/yaml.v2"
+)
+
+type optTestConfig struct {
+	Retries Opt[int]           `json:"retries" yaml:"retries"`
+	Enabled Opt[bool]          `json:"enabled" yaml:"enabled"`
+	Timeout Opt[time.Duration] `json:"timeout" yaml:"timeout"`
+	Name    string             `json:"name" yaml:"name"`
+}
+
+func TestMergeOpt(t *testing.T) {
+	dst := optTestConfig{Retries: Some(3), Enabled: Some(true), Name: `api`}
+	src := optTestConfig{Retries: Some(0), Enabled: Some(false)}
+	if err := Merge(&dst, src, WithOverride); err != nil {
+		t.Fatal(`error running Merge: ` + err.Error())
+	}
+	want := optTestConfig{Retries: Some(0), Enabled: Some(false), Name: `api`}
+	if !reflect.DeepEqual(dst, want) {
+		spew.Dump(dst)
+		t.Fatal(`Merge WithOverride did not apply the explicit zero values`)
+	}
+
+	dst = optTestConfig{Retries: Some(3)}
+	if err := Merge(&dst, optTestConfig{Retries: Some(5), Timeout: Some(time.Second)}); err != nil {
+		t.Fatal(`error running Merge: ` + err.Error())
+	}
+	if dst.Retries.Get() != 3 || dst.Timeout.GetOr(0) != time.Second || dst.Enabled.IsSet() {
+		spew.Dump(dst)
+		t.Fatal(`Merge should only fill the unset values`)
+	}
+}
+
+func TestOptDecoding(t *testing.T) {
+	want := optTestConfig{Retries: Some(0), Enabled: Some(false), Name: `api`}
+
+	var fromJSON optTestConfig
+	if err := json.Unmarshal([]byte(`{"retries": 0, "enabled": false, "timeout": null, "name": "api"}`), &fromJSON); err != nil {
+		t.Fatal(`error decoding JSON: ` + err.Error())
+	}
+	if !reflect.DeepEqual(fromJSON, want) {
+		spew.Dump(fromJSON)
+		t.Fatal(`JSON decoding did not set the present values only`)
+	}
+	out, err := json.Marshal(want)
+	if err != nil {
+		t.Fatal(`error encoding JSON: ` + err.Error())
+	}
+	if string(out) != `{"retries":0,"enabled":false,"timeout":null,"name":"api"}` {
+		t.Fatal(`unexpected JSON: ` + string(out))
+	}
+
+	var fromYAML optTestConfig
+	if err := yaml.Unmarshal([]byte("retries: 0\nenabled: false\ntimeout:\nname: api\n"), &fromYAML); err != nil {
+		t.Fatal(`error decoding YAML: ` + err.Error())
+	}
+	if !reflect.DeepEqual(fromYAML, want) {
+		spew.Dump(fromYAML)
+		t.Fatal(`YAML decoding did not set the present values only`)
+	}
+}
+
+func TestMapOpt(t *testing.T) {
+	var dst optTestConfig
+	src := map[string]interface{}{`retries`: 0, `enabled`: `false`}
+	if err := Map(&dst, src, WithWeaklyTypedInput); err != nil {
+		t.Fatal(`error running Map: ` + err.Error())
+	}
+	if !reflect.DeepEqual(dst, optTestConfig{Retries: Some(0), Enabled: Some(false)}) {
+		spew.Dump(dst)
+		t.Fatal(`Map did not set the Opt fields`)
+	}
+	if err := Map(&dst, map[string]interface{}{`retries`: `x`}, WithOverride); err == nil {
+		t.Fatal(`expected a type mismatch mapping a string into Opt[int]`)
+	}
+
+	m := map[string]interface{}{}
+	if err := Map(&m, optTestConfig{Retries: Some(0), Name: `api`}); err != nil {
+		t.Fatal(`error running Map: ` + err.Error())
+	}
+	if !reflect.DeepEqual(m, map[string]interface{}{`retries`: 0, `name`: `api`}) {
+		spew.Dump(m)
+		t.Fatal(`Map did not unwrap the set Opt fields`)
+	}
+}
+
+func TestOptFromEnvironment(t *testing.T) {
+	os.Setenv(`MSVC_Retries`, `0`)
+	os.Setenv(`MSVC_TIMEOUT`, `5s`)
+	defer os.Unsetenv(`MSVC_Retries`)
+	defer os.Unsetenv(`MSVC_TIMEOUT`)
+
+	dst := optTestConfig{Retries: Some(3)}
+	if err := Merge(&dst, optTestConfig{Name: `api`}); err != nil {
+		t.Fatal(`error running Merge: ` + err.Error())
+	}
+	if !dst.Retries.IsSet() || dst.Retries.Get() != 0 || dst.Timeout.Get() != 5*time.Second {
+		spew.Dump(dst)
+		t.Fatal(`environment overrides did not set the Opt fields`)
+	}
+}
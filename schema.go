@@ -0,0 +1,476 @@
+package mergo
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SchemaDialect is the JSON Schema version GenerateSchema emits.
+const SchemaDialect = `https://json-schema.org/draft/2020-12/schema`
+
+// FieldTagEnum is the struct tag listing the values a field may take, separated by
+// commas, e.g. `enum:"debug,info,warn"`.
+const FieldTagEnum string = `enum`
+
+// durationPattern matches the strings time.ParseDuration accepts.
+const durationPattern = `^[-+]?(0|([0-9]*(\.[0-9]*)?(ns|us|µs|μs|ms|s|m|h))+)$`
+
+var (
+	timeType            = reflect.TypeOf(time.Time{})
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// Schema is a JSON Schema, as GenerateSchema emits it. It marshals to and from JSON.
+type Schema struct {
+	Schema               string             `json:"$schema,omitempty"`
+	Type                 SchemaType         `json:"type,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Enum                 []interface{}      `json:"enum,omitempty"`
+	Default              interface{}        `json:"default,omitempty"`
+	ReadOnly             bool               `json:"readOnly,omitempty"`
+	WriteOnly            bool               `json:"writeOnly,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+
+	// never marks the false schema, which no value is valid against.
+	never bool
+}
+
+// SchemaType lists the JSON types a Schema allows, marshaled as a single string when
+// there is only one.
+type SchemaType []string
+
+func (t SchemaType) MarshalJSON() ([]byte, error) {
+	if len(t) == 1 {
+		return json.Marshal(t[0])
+	}
+	return json.Marshal([]string(t))
+}
+
+func (t *SchemaType) UnmarshalJSON(data []byte) error {
+	var one string
+	if err := json.Unmarshal(data, &one); err == nil {
+		*t = SchemaType{one}
+		return nil
+	}
+	return json.Unmarshal(data, (*[]string)(t))
+}
+
+func (s *Schema) MarshalJSON() ([]byte, error) {
+	if s.never {
+		return []byte(`false`), nil
+	}
+	type schema Schema
+	return json.Marshal((*schema)(s))
+}
+
+func (s *Schema) UnmarshalJSON(data []byte) error {
+	switch string(bytes.TrimSpace(data)) {
+	case `true`:
+		*s = Schema{}
+		return nil
+	case `false`:
+		*s = Schema{never: true}
+		return nil
+	}
+	type schema Schema
+	return json.Unmarshal(data, (*schema)(s))
+}
+
+// GenerateSchema returns the JSON Schema of documents decoding into the struct v, or v
+// points to, e.g. to check override files in CI before they are deployed.
+// Fields are walked as Merge does: fields without the optional tag, other than Opt, are
+// required, final fields are readOnly and secret fields writeOnly, and the `default`,
+// `enum` and `description` tags give the default, enum and description of a field.
+// Structs don't allow unknown properties.
+// Properties are named as YAML files name them: after the yaml tag, or else the field name
+// in lower case, with `yaml:",inline"` structs flattened. Use WithTagName to name them
+// after another tag, such as json, where fields without a name under the tag keep their
+// Go name and embedded structs are flattened.
+func GenerateSchema(v interface{}, opts ...func(*Config)) (*Schema, error) {
+	typ := reflect.TypeOf(v)
+	for typ != nil && typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if typ == nil || typ.Kind() != reflect.Struct {
+		return nil, ErrNotSupported
+	}
+	config := &Config{}
+	for _, opt := range opts {
+		opt(config)
+	}
+	g := &schemaGenerator{tagName: config.tagName, walking: map[reflect.Type]bool{}}
+	if g.tagName == `` {
+		g.tagName = `yaml`
+	}
+	s, err := g.typeSchema(typ)
+	if err != nil {
+		return nil, err
+	}
+	s.Schema = SchemaDialect
+	return s, nil
+}
+
+type schemaGenerator struct {
+	tagName string
+	walking map[reflect.Type]bool
+}
+
+func (g *schemaGenerator) typeSchema(typ reflect.Type) (*Schema, error) {
+	if isOptionalType(typ) {
+		return g.typeSchema(leafType(typ))
+	}
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	switch typ {
+	case durationType:
+		return &Schema{Type: SchemaType{`string`, `integer`}, Pattern: durationPattern}, nil
+	case timeType:
+		return &Schema{Type: SchemaType{`string`}, Format: `date-time`}, nil
+	}
+	switch k := typ.Kind(); {
+	case k == reflect.Bool:
+		return &Schema{Type: SchemaType{`boolean`}}, nil
+	case k == reflect.String:
+		return &Schema{Type: SchemaType{`string`}}, nil
+	case k == reflect.Float32 || k == reflect.Float64:
+		return &Schema{Type: SchemaType{`number`}}, nil
+	case isIntKind(k):
+		return &Schema{Type: SchemaType{`integer`}}, nil
+	case isUintKind(k):
+		min := 0.0
+		return &Schema{Type: SchemaType{`integer`}, Minimum: &min}, nil
+	case k == reflect.Slice && typ.Elem().Kind() == reflect.Uint8:
+		return &Schema{Type: SchemaType{`string`}}, nil
+	case k == reflect.Slice || k == reflect.Array:
+		items, err := g.typeSchema(typ.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return &Schema{Type: SchemaType{`array`}, Items: items}, nil
+	case k == reflect.Map:
+		s := &Schema{Type: SchemaType{`object`}}
+		if typ.Key().Kind() == reflect.String {
+			values, err := g.typeSchema(typ.Elem())
+			if err != nil {
+				return nil, err
+			}
+			s.AdditionalProperties = values
+		}
+		return s, nil
+	case k == reflect.Struct:
+		if reflect.PtrTo(typ).Implements(textUnmarshalerType) {
+			return &Schema{Type: SchemaType{`string`}}, nil
+		}
+		if !hasMergeableFields(reflect.New(typ).Elem()) {
+			return &Schema{}, nil
+		}
+		return g.structSchema(typ)
+	}
+	// interfaces take anything; channels and funcs are never decoded
+	return &Schema{}, nil
+}
+
+func (g *schemaGenerator) structSchema(typ reflect.Type) (*Schema, error) {
+	if g.walking[typ] {
+		// a recursive type, as in a linked list, is described down to the first repeat
+		return &Schema{Type: SchemaType{`object`}}, nil
+	}
+	g.walking[typ] = true
+	defer delete(g.walking, typ)
+
+	s := &Schema{
+		Type:                 SchemaType{`object`},
+		Properties:           map[string]*Schema{},
+		AdditionalProperties: &Schema{never: true},
+	}
+	if err := g.addProperties(s, typ); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (g *schemaGenerator) addProperties(s *Schema, typ reflect.Type) error {
+	for i, n := 0, typ.NumField(); i < n; i++ {
+		sf := typ.Field(i)
+		if !isExportedComponent(&sf) {
+			continue
+		}
+		name, inline, skip := g.propertyName(sf)
+		if skip {
+			continue
+		}
+		if inline {
+			st := sf.Type
+			if st.Kind() == reflect.Ptr {
+				st = st.Elem()
+			}
+			if st.Kind() == reflect.Struct && !isOptionalType(st) {
+				if err := g.addProperties(s, st); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		fs, err := g.typeSchema(sf.Type)
+		if err != nil {
+			return err
+		}
+		fi := parseField(sf)
+		fs.Description = sf.Tag.Get(FieldTagDescription)
+		fs.ReadOnly = fi.Final
+		fs.WriteOnly = fi.Secret
+		if fi.Default != `` {
+			value, err := parseFieldValue(fi.Default, sf.Type)
+			if err != nil {
+				return fmt.Errorf("invalid default for %s.%s: %w", typ, sf.Name, err)
+			}
+			fs.Default = schemaValue(value)
+		}
+		if enum, ok := sf.Tag.Lookup(FieldTagEnum); ok {
+			target := fs
+			if fs.Items != nil {
+				target = fs.Items
+			}
+			for _, part := range strings.Split(enum, `,`) {
+				value, err := parseValue(strings.TrimSpace(part), leafType(sf.Type))
+				if err != nil {
+					return fmt.Errorf("invalid enum for %s.%s: %w", typ, sf.Name, err)
+				}
+				target.Enum = append(target.Enum, schemaValue(value))
+			}
+		}
+		if !fi.Optional && !isOptionalType(sf.Type) {
+			s.Required = append(s.Required, name)
+		}
+		s.Properties[name] = fs
+	}
+	return nil
+}
+
+// propertyName returns the name of the property of a struct field, whether its fields
+// are flattened into the enclosing object instead, and whether it is left out.
+func (g *schemaGenerator) propertyName(sf reflect.StructField) (name string, inline, skip bool) {
+	tag, _ := sf.Tag.Lookup(g.tagName)
+	if tag == `-` {
+		return ``, false, true
+	}
+	name = strings.Split(tag, `,`)[0]
+	if name == `` {
+		if g.tagName == `yaml` {
+			name = strings.ToLower(sf.Name)
+		} else {
+			name = sf.Name
+		}
+	}
+	return name, inlineField(sf, g.tagName), false
+}
+
+// schemaValue returns v as it appears in a document, for a default or enum value.
+func schemaValue(v reflect.Value) interface{} {
+	if opt, ok := asOptional(v); ok {
+		value, set := opt.optionalValue()
+		if !set {
+			return nil
+		}
+		return schemaValue(value)
+	}
+	switch {
+	case v.Type() == durationType:
+		return time.Duration(v.Int()).String()
+	case v.Kind() == reflect.Ptr:
+		if v.IsNil() {
+			return nil
+		}
+		return schemaValue(v.Elem())
+	case v.Kind() == reflect.Slice && v.Type().Elem().Kind() != reflect.Uint8:
+		values := make([]interface{}, v.Len())
+		for i := range values {
+			values[i] = schemaValue(v.Index(i))
+		}
+		return values
+	}
+	switch k := v.Kind(); {
+	case k == reflect.Bool:
+		return v.Bool()
+	case k == reflect.String:
+		return v.String()
+	case isIntKind(k):
+		return v.Int()
+	case isUintKind(k):
+		return v.Uint()
+	case k == reflect.Float32 || k == reflect.Float64:
+		return v.Float()
+	}
+	return v.Interface()
+}
+
+// SchemaError reports a value of a document that is not valid against a Schema.
+type SchemaError struct {
+	// Path is the dotted path of the value, with indexes in brackets, e.g. hosts[1].
+	Path    string
+	Message string
+}
+
+func (e *SchemaError) Error() string {
+	if e.Path == `` {
+		return e.Message
+	}
+	return e.Path + `: ` + e.Message
+}
+
+// Validate checks doc, such as a YAML or JSON file decoded into a map, against s and
+// returns a *SchemaError for each invalid value, or nil if doc is valid. Nested maps may
+// have interface{} keys, as yaml.v2 decodes them. readOnly and writeOnly are annotations,
+// and don't make a document invalid.
+func (s *Schema) Validate(doc map[string]interface{}) []error {
+	var errs []error
+	s.validate(doc, ``, &errs)
+	return errs
+}
+
+func (s *Schema) validate(value interface{}, path string, errs *[]error) {
+	fail := func(format string, args ...interface{}) {
+		*errs = append(*errs, &SchemaError{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+	if s.never {
+		fail(`no value is allowed`)
+		return
+	}
+	actual := jsonType(value)
+	if len(s.Type) > 0 && !s.Type.allows(actual) {
+		fail("expected %s, got %s", strings.Join(s.Type, ` or `), actual)
+		return
+	}
+	if len(s.Enum) > 0 && !enumContains(s.Enum, value) {
+		fail("%v is not one of %v", value, s.Enum)
+	}
+
+	v := reflect.ValueOf(value)
+	switch actual {
+	case `string`:
+		if s.Pattern != `` {
+			re, err := regexp.Compile(s.Pattern)
+			if err != nil {
+				fail("invalid pattern %q: %v", s.Pattern, err)
+			} else if !re.MatchString(v.String()) {
+				fail("%q does not match %s", v.String(), s.Pattern)
+			}
+		}
+		if s.Format == `date-time` {
+			if _, err := time.Parse(time.RFC3339, v.String()); err != nil {
+				fail("%q is not a date-time", v.String())
+			}
+		}
+	case `integer`, `number`:
+		if s.Minimum != nil && numberValue(v) < *s.Minimum {
+			fail("%v is less than %v", value, *s.Minimum)
+		}
+	case `object`:
+		properties := map[string]interface{}{}
+		keys := make([]string, 0, v.Len())
+		for _, key := range v.MapKeys() {
+			name := fmt.Sprint(key.Interface())
+			properties[name] = v.MapIndex(key).Interface()
+			keys = append(keys, name)
+		}
+		sort.Strings(keys)
+		for _, name := range s.Required {
+			if _, ok := properties[name]; !ok {
+				*errs = append(*errs, &SchemaError{Path: joinFieldPath(path, name), Message: `required property is missing`})
+			}
+		}
+		for _, name := range keys {
+			if property, ok := s.Properties[name]; ok {
+				property.validate(properties[name], joinFieldPath(path, name), errs)
+			} else if s.AdditionalProperties != nil && s.AdditionalProperties.never {
+				*errs = append(*errs, &SchemaError{Path: joinFieldPath(path, name), Message: `unknown property`})
+			} else if s.AdditionalProperties != nil {
+				s.AdditionalProperties.validate(properties[name], joinFieldPath(path, name), errs)
+			}
+		}
+	case `array`:
+		if s.Items != nil {
+			for i := 0; i < v.Len(); i++ {
+				s.Items.validate(v.Index(i).Interface(), joinIndexPath(path, i), errs)
+			}
+		}
+	}
+}
+
+// allows reports whether a value of the JSON type actual is valid against t, where
+// integers are numbers too.
+func (t SchemaType) allows(actual string) bool {
+	for _, typ := range t {
+		if typ == actual || (typ == `number` && actual == `integer`) {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonType returns the JSON type of a decoded value, where floats without a fractional
+// part are integers, or its Go type if it has none.
+func jsonType(value interface{}) string {
+	if value == nil {
+		return `null`
+	}
+	v := reflect.ValueOf(value)
+	switch k := v.Kind(); {
+	case k == reflect.Bool:
+		return `boolean`
+	case k == reflect.String:
+		return `string`
+	case isIntKind(k) || isUintKind(k):
+		return `integer`
+	case k == reflect.Float32 || k == reflect.Float64:
+		if f := v.Float(); f == math.Trunc(f) && !math.IsInf(f, 0) {
+			return `integer`
+		}
+		return `number`
+	case k == reflect.Map:
+		return `object`
+	case k == reflect.Slice || k == reflect.Array:
+		return `array`
+	}
+	return v.Type().String()
+}
+
+func enumContains(enum []interface{}, value interface{}) bool {
+	v := reflect.ValueOf(value)
+	for _, e := range enum {
+		ev := reflect.ValueOf(e)
+		if value != nil && e != nil && isNumberKind(v.Kind()) && isNumberKind(ev.Kind()) {
+			if numberValue(v) == numberValue(ev) {
+				return true
+			}
+		} else if reflect.DeepEqual(e, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func numberValue(v reflect.Value) float64 {
+	switch k := v.Kind(); {
+	case isIntKind(k):
+		return float64(v.Int())
+	case isUintKind(k):
+		return float64(v.Uint())
+	}
+	return v.Float()
+}
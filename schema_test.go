@@ -0,0 +1,228 @@
+package mergo
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/davecgh/go-spew/spew"
+	"gopkg.in/yaml.v2"
+)
+
+type schemaTestConfig struct {
+	RequiredConfig `yaml:",inline"`
+	Level          string            `yaml:"level" enum:"debug,info,warn" default:"info"`
+	Timeout        time.Duration     `yaml:"timeout" default:"30s" description:"how long to wait"`
+	Retries        Opt[uint]         `yaml:"retries"`
+	Password       string            `yaml:"password" config:"secret,optional"`
+	Hosts          []string          `yaml:"hosts" config:"optional" default:"a,b"`
+	Labels         map[string]string `config:"optional"`
+	Database       *schemaTestDatabase
+	Ignored        string `yaml:"-"`
+}
+
+type schemaTestDatabase struct {
+	Port int `yaml:"port" enum:"5432,5433"`
+}
+
+func TestGenerateSchema(t *testing.T) {
+	schema, err := GenerateSchema(&schemaTestConfig{})
+	if err != nil {
+		t.Fatal(`error running GenerateSchema: ` + err.Error())
+	}
+	schema.Properties = map[string]*Schema{
+		`OverrideConfigPath`: schema.Properties[`OverrideConfigPath`],
+		`level`:              schema.Properties[`level`],
+		`timeout`:            schema.Properties[`timeout`],
+		`retries`:            schema.Properties[`retries`],
+		`password`:           schema.Properties[`password`],
+		`hosts`:              schema.Properties[`hosts`],
+		`labels`:             schema.Properties[`labels`],
+		`database`:           schema.Properties[`database`],
+	}
+	schema.Required = schema.Required[len(schema.Required)-3:]
+	got, err := json.MarshalIndent(schema, ``, `  `)
+	if err != nil {
+		t.Fatal(`error marshaling schema: ` + err.Error())
+	}
+	want := `{
+  "$schema": "https://json-schema.org/draft/2020-12/schema",
+  "type": "object",
+  "properties": {
+    "OverrideConfigPath": {
+      "type": "string",
+      "readOnly": true
+    },
+    "database": {
+      "type": "object",
+      "properties": {
+        "port": {
+          "type": "integer",
+          "enum": [
+            5432,
+            5433
+          ]
+        }
+      },
+      "required": [
+        "port"
+      ],
+      "additionalProperties": false
+    },
+    "hosts": {
+      "type": "array",
+      "default": [
+        "a",
+        "b"
+      ],
+      "items": {
+        "type": "string"
+      }
+    },
+    "labels": {
+      "type": "object",
+      "additionalProperties": {
+        "type": "string"
+      }
+    },
+    "level": {
+      "type": "string",
+      "enum": [
+        "debug",
+        "info",
+        "warn"
+      ],
+      "default": "info"
+    },
+    "password": {
+      "type": "string",
+      "writeOnly": true
+    },
+    "retries": {
+      "type": "integer",
+      "minimum": 0
+    },
+    "timeout": {
+      "type": [
+        "string",
+        "integer"
+      ],
+      "description": "how long to wait",
+      "pattern": "^[-+]?(0|([0-9]*(\\.[0-9]*)?(ns|us|µs|μs|ms|s|m|h))+)$",
+      "default": "30s"
+    }
+  },
+  "required": [
+    "level",
+    "timeout",
+    "database"
+  ],
+  "additionalProperties": false
+}`
+	if string(got) != want {
+		t.Fatal("unexpected schema:\n" + string(got))
+	}
+
+	schema, err = GenerateSchema(schemaTestConfig{}, WithTagName(`json`))
+	if err != nil {
+		t.Fatal(`error running GenerateSchema: ` + err.Error())
+	}
+	for _, name := range []string{`Environment`, `Level`, `Database`, `Ignored`} {
+		if schema.Properties[name] == nil {
+			spew.Dump(schema.Properties)
+			t.Fatal(`expected properties named after the json tag or the field name`)
+		}
+	}
+
+	if _, err = GenerateSchema(`not a struct`); err != ErrNotSupported {
+		t.Fatal(`expected ErrNotSupported for a non-struct value`)
+	}
+	type badDefault struct {
+		Port int `default:"eighty"`
+	}
+	if _, err = GenerateSchema(badDefault{}); err == nil {
+		t.Fatal(`expected an error for an invalid default`)
+	}
+}
+
+func TestSchemaValidate(t *testing.T) {
+	schema, err := GenerateSchema(schemaTestConfig{})
+	if err != nil {
+		t.Fatal(`error running GenerateSchema: ` + err.Error())
+	}
+	var doc map[string]interface{}
+	err = yaml.Unmarshal([]byte(`
+Environment: prod
+LogLevel: info
+Version: "1"
+Branch: main
+Commit: abc
+ImageTag: latest
+Build: "7"
+DateBuilt: today
+level: info
+timeout: 1m30s
+retries: 3
+hosts: [a, b]
+labels: {team: core}
+database: {port: 5432}
+`), &doc)
+	if err != nil {
+		t.Fatal(`error decoding document: ` + err.Error())
+	}
+	if errs := schema.Validate(doc); errs != nil {
+		spew.Dump(errs)
+		t.Fatal(`expected a valid document`)
+	}
+
+	doc = map[string]interface{}{
+		`Environment`: `prod`, `LogLevel`: `info`, `Version`: 1, `Branch`: `main`,
+		`Commit`: `abc`, `ImageTag`: `latest`, `Build`: 7, `DateBuilt`: `today`,
+		`level`:    `trace`,
+		`timeout`:  `soon`,
+		`retries`:  -1,
+		`hosts`:    []interface{}{`a`, 2},
+		`labels`:   map[string]interface{}{`team`: true},
+		`database`: map[interface{}]interface{}{`port`: 5432.0, `host`: `db`},
+	}
+	wantMessages := []string{
+		`Build: expected string, got integer`,
+		`Version: expected string, got integer`,
+		`database.host: unknown property`,
+		`hosts[1]: expected string, got integer`,
+		`labels.team: expected string, got boolean`,
+		`level: trace is not one of [debug info warn]`,
+		`retries: -1 is less than 0`,
+		`timeout: "soon" does not match ` + durationPattern,
+	}
+	var messages []string
+	for _, err := range schema.Validate(doc) {
+		var schemaErr *SchemaError
+		if !errors.As(err, &schemaErr) {
+			t.Fatal(`expected a *SchemaError`)
+		}
+		messages = append(messages, err.Error())
+	}
+	if !reflect.DeepEqual(messages, wantMessages) {
+		spew.Dump(messages)
+		t.Fatal(`Validate did not report the expected errors`)
+	}
+
+	// a schema read back from JSON validates the same way
+	data, err := json.Marshal(schema)
+	if err != nil {
+		t.Fatal(`error marshaling schema: ` + err.Error())
+	}
+	var decoded Schema
+	if err = json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(`error unmarshaling schema: ` + err.Error())
+	}
+	delete(doc, `level`)
+	errs := decoded.Validate(doc)
+	if len(errs) != len(wantMessages) || errs[0].Error() != `level: required property is missing` {
+		spew.Dump(errs)
+		t.Fatal(`the decoded schema did not validate as the generated one`)
+	}
+}
@@ -0,0 +1,104 @@
+package mergo
+
+import (
+	"reflect"
+	"sync"
+	"sync/atomic"
+)
+
+// Store holds a configuration value of type T, a struct, a map or a pointer to a struct,
+// that goroutines can Load while others Update it. Each update builds a new value, so a
+// loaded snapshot never changes; it must not be modified either.
+// The zero Store holds the zero value of T.
+type Store[T any] struct {
+	value       atomic.Value
+	mu          sync.Mutex
+	subscribers []func(changes []Change, old, new T)
+}
+
+// storeSnapshot wraps the values of a Store, since atomic.Value needs them all to have
+// the same concrete type.
+type storeSnapshot[T any] struct {
+	value T
+}
+
+// NewStore returns a Store holding v.
+func NewStore[T any](v T) *Store[T] {
+	s := &Store[T]{}
+	s.value.Store(storeSnapshot[T]{v})
+	return s
+}
+
+// Load returns the current value.
+func (s *Store[T]) Load() T {
+	snapshot, _ := s.value.Load().(storeSnapshot[T])
+	return snapshot.value
+}
+
+// Update calls fn with a copy of the current value and merges what it returns, WithOverride
+// and the given options, into another copy, which becomes the current value. fn may
+// modify and return its argument, or return a value holding only the changes.
+// As with Merge, empty values are not applied unless an option such as
+// WithOverwriteWithEmptyValue says so, and final fields are never changed.
+// When T is a pointer and the Store holds nil, fn gets a pointer to a zero value, which
+// the changes are relative to; if fn returns nil, the Store holds nil again.
+// If anything changed, subscribers are then called, in the goroutine of Update.
+// Updates run one at a time, but subscribers are called once the next Update may start,
+// so they may Load, Subscribe and Update; they are not called in order when several
+// goroutines Update the Store.
+func (s *Store[T]) Update(fn func(T) T, opts ...func(*Config)) error {
+	old, next, changes, subscribers, err := s.update(fn, opts)
+	if err != nil {
+		return err
+	}
+	for _, subscriber := range subscribers {
+		subscriber(changes, old, next)
+	}
+	return nil
+}
+
+// update does the work of Update under s.mu, returning the subscribers to call when
+// the value changed.
+func (s *Store[T]) update(fn func(T) T, opts []func(*Config)) (old, next T, changes []Change, subscribers []func(changes []Change, old, new T), err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	old = s.Load()
+	base := old
+	if v := reflect.ValueOf(old); v.Kind() == reflect.Ptr && v.IsNil() {
+		// the zero Store of a pointer type holds nil, so the first update starts from a new value
+		base = reflect.New(v.Type().Elem()).Interface().(T)
+	}
+	src := fn(cloneOf(base))
+	if v := reflect.ValueOf(src); v.Kind() == reflect.Ptr && v.IsNil() {
+		next = src
+		base = old
+	} else {
+		next = cloneOf(base)
+		var dst interface{} = &next
+		if v := reflect.ValueOf(next); v.Kind() == reflect.Ptr {
+			dst = next
+		}
+		if err = Merge(dst, src, append([]func(*Config){WithOverride}, opts...)...); err != nil {
+			return
+		}
+	}
+	if changes, err = Diff(base, next); err != nil || len(changes) == 0 {
+		return
+	}
+	s.value.Store(storeSnapshot[T]{next})
+	subscribers = append(subscribers, s.subscribers...)
+	return
+}
+
+// Subscribe makes the Store call fn after each Update that changed the value, with the
+// changes as reported by Diff.
+func (s *Store[T]) Subscribe(fn func(changes []Change, old, new T)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.subscribers = append(s.subscribers, fn)
+}
+
+func cloneOf[T any](v T) T {
+	c, _ := Clone(v).(T)
+	return c
+}
@@ -0,0 +1,205 @@
+package mergo
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/davecgh/go-spew/spew"
+)
+
+type storeTestConfig struct {
+	Replicas int
+	Hosts    []string
+	Labels   map[string]string
+}
+
+func TestStore(t *testing.T) {
+	store := NewStore(storeTestConfig{Replicas: 1, Hosts: []string{`a`}, Labels: map[string]string{`team`: `core`}})
+	var notified [][]Change
+	store.Subscribe(func(changes []Change, old, new storeTestConfig) {
+		notified = append(notified, changes)
+	})
+
+	first := store.Load()
+	err := store.Update(func(c storeTestConfig) storeTestConfig {
+		c.Labels[`tier`] = `web`
+		return c
+	})
+	if err != nil {
+		t.Fatal(`error running Update: ` + err.Error())
+	}
+	err = store.Update(func(storeTestConfig) storeTestConfig {
+		return storeTestConfig{Replicas: 3}
+	})
+	if err != nil {
+		t.Fatal(`error running Update: ` + err.Error())
+	}
+	if err = store.Update(func(c storeTestConfig) storeTestConfig { return c }); err != nil {
+		t.Fatal(`error running Update: ` + err.Error())
+	}
+
+	want := storeTestConfig{Replicas: 3, Hosts: []string{`a`}, Labels: map[string]string{`team`: `core`, `tier`: `web`}}
+	if !reflect.DeepEqual(store.Load(), want) {
+		spew.Dump(store.Load())
+		t.Fatal(`Update did not merge the changes`)
+	}
+	if len(first.Labels) != 1 {
+		t.Fatal(`Update modified a loaded snapshot`)
+	}
+	wantNotified := [][]Change{
+		{{Type: ChangeAdded, Path: `Labels[tier]`, To: `web`}},
+		{{Type: ChangeModified, Path: `Replicas`, From: 1, To: 3}},
+	}
+	if !reflect.DeepEqual(notified, wantNotified) {
+		spew.Dump(notified)
+		t.Fatal(`subscribers did not receive the changes of each Update`)
+	}
+}
+
+func TestStoreConcurrency(t *testing.T) {
+	store := NewStore(&storeTestConfig{Hosts: []string{`a`}})
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				if c := store.Load(); len(c.Hosts) != 1 {
+					t.Error(`loaded an inconsistent snapshot`)
+					return
+				}
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				err := store.Update(func(c *storeTestConfig) *storeTestConfig {
+					c.Replicas++
+					return c
+				})
+				if err != nil {
+					t.Error(`error running Update: ` + err.Error())
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if store.Load().Replicas != 400 {
+		t.Fatalf(`expected 400 updates, got %d`, store.Load().Replicas)
+	}
+
+	var empty Store[storeTestConfig]
+	if !reflect.DeepEqual(empty.Load(), storeTestConfig{}) {
+		t.Fatal(`expected the zero Store to hold the zero value`)
+	}
+}
+
+func TestStoreZeroPointer(t *testing.T) {
+	var store Store[*storeTestConfig]
+	if store.Load() != nil {
+		t.Fatal(`expected the zero Store to hold nil`)
+	}
+	var notified []Change
+	store.Subscribe(func(changes []Change, old, new *storeTestConfig) {
+		notified = changes
+	})
+	err := store.Update(func(c *storeTestConfig) *storeTestConfig {
+		c.Replicas = 2
+		return c
+	})
+	if err != nil {
+		t.Fatal(`error running Update: ` + err.Error())
+	}
+	if c := store.Load(); c == nil || c.Replicas != 2 {
+		spew.Dump(c)
+		t.Fatal(`Update did not set the value of a zero Store`)
+	}
+	if len(notified) == 0 {
+		t.Fatal(`expected subscribers to be notified of the first value`)
+	}
+}
+
+type storeTestFinalConfig struct {
+	Name     string `config:"final"`
+	Replicas int
+	Enabled  bool
+	Labels   map[string]string
+}
+
+func TestStoreZeroValues(t *testing.T) {
+	store := NewStore(storeTestFinalConfig{Name: `svc`, Replicas: 3, Enabled: true, Labels: map[string]string{`team`: `core`}})
+	err := store.Update(func(c storeTestFinalConfig) storeTestFinalConfig {
+		c.Name = ``
+		c.Replicas = 0
+		c.Enabled = false
+		return c
+	}, WithOverwriteWithEmptyValue)
+	if err != nil {
+		t.Fatal(`error running Update: ` + err.Error())
+	}
+	want := storeTestFinalConfig{Name: `svc`, Labels: map[string]string{`team`: `core`}}
+	if !reflect.DeepEqual(store.Load(), want) {
+		spew.Dump(store.Load())
+		t.Fatal(`Update did not apply the zero values`)
+	}
+}
+
+func TestStoreNilResult(t *testing.T) {
+	store := NewStore(&storeTestConfig{Replicas: 1})
+	var notified []Change
+	store.Subscribe(func(changes []Change, old, new *storeTestConfig) {
+		notified = changes
+	})
+	if err := store.Update(func(*storeTestConfig) *storeTestConfig { return nil }); err != nil {
+		t.Fatal(`error running Update: ` + err.Error())
+	}
+	if store.Load() != nil || len(notified) != 1 || notified[0].Type != ChangeRemoved {
+		spew.Dump(store.Load(), notified)
+		t.Fatal(`expected a nil result to be stored`)
+	}
+}
+
+func TestStoreUpdatePanic(t *testing.T) {
+	store := NewStore(storeTestConfig{})
+	func() {
+		defer func() { _ = recover() }()
+		_ = store.Update(func(storeTestConfig) storeTestConfig { panic(`boom`) })
+	}()
+	err := store.Update(func(c storeTestConfig) storeTestConfig {
+		c.Replicas = 1
+		return c
+	})
+	if err != nil || store.Load().Replicas != 1 {
+		t.Fatal(`expected the Store to be usable after a panic in Update`)
+	}
+}
+
+func TestStoreSubscriberReentry(t *testing.T) {
+	store := NewStore(storeTestConfig{})
+	var loaded storeTestConfig
+	store.Subscribe(func(changes []Change, old, new storeTestConfig) {
+		loaded = store.Load()
+		store.Subscribe(func([]Change, storeTestConfig, storeTestConfig) {})
+	})
+	done := make(chan error)
+	go func() {
+		done <- store.Update(func(c storeTestConfig) storeTestConfig {
+			c.Replicas = 1
+			return c
+		})
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(`error running Update: ` + err.Error())
+		}
+	case <-time.After(time.Second):
+		t.Fatal(`subscriber calling the Store deadlocked`)
+	}
+	if loaded.Replicas != 1 {
+		t.Fatal(`subscriber did not load the new value`)
+	}
+}
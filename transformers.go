@@ -0,0 +1,144 @@
+package mergo
+
+import (
+	"reflect"
+)
+
+// TransformerFunc merges src into dst in place of the default merge. settings are those
+// of the running merge, so a transformer can honor WithOverride and the other options.
+type TransformerFunc func(dst, src reflect.Value, settings MergeSettings) error
+
+// MergeSettings are the options of a running merge that a TransformerFunc may honor.
+type MergeSettings struct {
+	// Overwrite is set by WithOverride.
+	Overwrite bool
+	// OverwriteWithEmptyValue is set by WithOverwriteWithEmptyValue.
+	OverwriteWithEmptyValue bool
+	// AppendSlice is set by WithAppendSlice.
+	AppendSlice bool
+	// TypeCheck is set by WithTypeCheck.
+	TypeCheck bool
+	// DeepCopy is set by WithDeepCopy.
+	DeepCopy bool
+}
+
+func (config *Config) mergeSettings() MergeSettings {
+	return MergeSettings{
+		Overwrite:               config.Overwrite,
+		OverwriteWithEmptyValue: config.overwriteWithEmptyValue,
+		AppendSlice:             config.AppendSlice,
+		TypeCheck:               config.TypeCheck,
+		DeepCopy:                config.deepCopy,
+	}
+}
+
+// TransformerSettings are the settings of a registered transformer, which
+// TransformerOptions change.
+type TransformerSettings struct {
+	// Empty makes the transformer run even when dst is empty.
+	Empty bool
+	// ThenMerge makes the default merge run after the transformer.
+	ThenMerge bool
+}
+
+// TransformerOption changes when and how a registered transformer runs.
+type TransformerOption func(*TransformerSettings)
+
+// TransformEmpty will make the transformer run even when dst is empty. By default,
+// as with Transformers, it only runs when dst holds a value.
+func TransformEmpty(settings *TransformerSettings) {
+	settings.Empty = true
+}
+
+// TransformThenMerge will make the default merge run after the transformer, on the
+// value the transformer left in dst, instead of the transformer replacing it.
+func TransformThenMerge(settings *TransformerSettings) {
+	settings.ThenMerge = true
+}
+
+type transformerEntry struct {
+	TransformerSettings
+	fn TransformerFunc
+}
+
+// TransformerRegistry holds transformers registered for a type, for the field at a
+// path or for the fields carrying a config tag. Pass it to WithTransformers.
+// When several transformers apply to a field, the one for its path wins over the one
+// for its tags, which wins over the one for its type.
+type TransformerRegistry struct {
+	types map[reflect.Type]*transformerEntry
+	paths map[string]*transformerEntry
+	tags  map[string]*transformerEntry
+}
+
+// NewTransformerRegistry returns an empty TransformerRegistry.
+func NewTransformerRegistry() *TransformerRegistry {
+	return &TransformerRegistry{
+		types: map[reflect.Type]*transformerEntry{},
+		paths: map[string]*transformerEntry{},
+		tags:  map[string]*transformerEntry{},
+	}
+}
+
+// RegisterType registers fn for every value of type typ.
+func (r *TransformerRegistry) RegisterType(typ reflect.Type, fn TransformerFunc, opts ...TransformerOption) *TransformerRegistry {
+	r.types[typ] = newTransformerEntry(fn, opts)
+	return r
+}
+
+// RegisterPath registers fn for the struct field at path, written as the dotted Go field
+// names from the merged value, e.g. "Database.Hosts". Fields of embedded structs are
+// reached without the embedded type's name, as in Diff.
+func (r *TransformerRegistry) RegisterPath(path string, fn TransformerFunc, opts ...TransformerOption) *TransformerRegistry {
+	r.paths[path] = newTransformerEntry(fn, opts)
+	return r
+}
+
+// RegisterTag registers fn for the struct fields whose config tag lists tag, e.g. "append"
+// for fields tagged `config:"append"`.
+func (r *TransformerRegistry) RegisterTag(tag string, fn TransformerFunc, opts ...TransformerOption) *TransformerRegistry {
+	r.tags[tag] = newTransformerEntry(fn, opts)
+	return r
+}
+
+// Transformer implements Transformers with the transformers registered by type, so a
+// registry can stand in wherever Transformers are expected. Transformers called this way
+// get zero MergeSettings, since Transformers don't pass any, while Merge, given the
+// registry itself WithTransformers, calls them with its own, honoring WithOverride and
+// the other options.
+func (r *TransformerRegistry) Transformer(typ reflect.Type) func(dst, src reflect.Value) error {
+	entry, ok := r.types[typ]
+	if !ok {
+		return nil
+	}
+	return func(dst, src reflect.Value) error {
+		return entry.fn(dst, src, MergeSettings{})
+	}
+}
+
+func newTransformerEntry(fn TransformerFunc, opts []TransformerOption) *transformerEntry {
+	entry := &transformerEntry{fn: fn}
+	for _, opt := range opts {
+		opt(&entry.TransformerSettings)
+	}
+	return entry
+}
+
+// lookup returns the transformer for dst, or nil if dst is invalid; field and path
+// describe the struct field holding it, and field is nil for anything else.
+func (r *TransformerRegistry) lookup(dst reflect.Value, field *FieldInfo, path string) *transformerEntry {
+	if !dst.IsValid() {
+		return nil
+	}
+	if field != nil {
+		if entry, ok := r.paths[path]; ok {
+			return entry
+		}
+		for _, tag := range field.Tags {
+			if entry, ok := r.tags[tag]; ok {
+				return entry
+			}
+		}
+	}
+	return r.types[dst.Type()]
+}
@@ -0,0 +1,126 @@
+// Package transformers provides mergo transformers for standard library types that
+// cannot be merged field by field, such as time.Time, *big.Int or net.IP.
+//
+// Each of these types is merged as a single value: src replaces an empty dst, or a
+// non-empty one when merging WithOverride, and an empty src never replaces dst.
+// Values taken from src are copied, so dst shares no memory with it.
+//
+//	err := mergo.Merge(&dst, src, mergo.WithTransformers(transformers.Standard()))
+//
+// Register adds the same transformers to a registry holding your own.
+package transformers
+
+import (
+	"database/sql"
+	"math/big"
+	"net"
+	"net/url"
+	"reflect"
+	"time"
+
+	"github.com/elephant-insurance/mergo"
+)
+
+// Standard returns a registry holding the transformers of this package.
+func Standard() *mergo.TransformerRegistry {
+	return Register(mergo.NewTransformerRegistry())
+}
+
+// Register adds the transformers of this package to r and returns it. Transformers
+// already registered in r for the same types are replaced.
+func Register(r *mergo.TransformerRegistry) *mergo.TransformerRegistry {
+	for _, t := range standard {
+		r.RegisterType(t.typ, Replace(t.isEmpty, t.copy), mergo.TransformEmpty)
+	}
+	return r
+}
+
+type replacer struct {
+	typ     reflect.Type
+	isEmpty func(v reflect.Value) bool
+	copy    func(v reflect.Value) reflect.Value
+}
+
+var standard = []replacer{
+	{reflect.TypeOf(time.Time{}), isZeroTime, nil},
+	{reflect.TypeOf(&big.Int{}), isNil, copyBigInt},
+	{reflect.TypeOf(&big.Float{}), isNil, copyBigFloat},
+	{reflect.TypeOf(&big.Rat{}), isNil, copyBigRat},
+	{reflect.TypeOf(net.IP{}), isEmptySlice, copySlice},
+	{reflect.TypeOf(net.IPMask{}), isEmptySlice, copySlice},
+	{reflect.TypeOf(net.IPNet{}), isEmptyIPNet, copyIPNet},
+	{reflect.TypeOf(url.URL{}), isEmptyURL, nil},
+	{reflect.TypeOf(sql.NullString{}), isInvalid, nil},
+	{reflect.TypeOf(sql.NullInt64{}), isInvalid, nil},
+	{reflect.TypeOf(sql.NullInt32{}), isInvalid, nil},
+	{reflect.TypeOf(sql.NullFloat64{}), isInvalid, nil},
+	{reflect.TypeOf(sql.NullBool{}), isInvalid, nil},
+	{reflect.TypeOf(sql.NullTime{}), isInvalid, nil},
+}
+
+// Replace returns a transformer merging a type as a single value, using isEmpty to tell
+// whether a value is set. copy, when not nil, copies src before it is stored in dst.
+// Use it with mergo.TransformEmpty to register the same behavior for your own types.
+func Replace(isEmpty func(v reflect.Value) bool, copy func(v reflect.Value) reflect.Value) mergo.TransformerFunc {
+	return func(dst, src reflect.Value, settings mergo.MergeSettings) error {
+		if !dst.CanSet() || isEmpty(src) || !(isEmpty(dst) || settings.Overwrite) {
+			return nil
+		}
+		if copy != nil {
+			src = copy(src)
+		}
+		dst.Set(src)
+		return nil
+	}
+}
+
+func isNil(v reflect.Value) bool {
+	return v.IsNil()
+}
+
+func isEmptySlice(v reflect.Value) bool {
+	return v.Len() == 0
+}
+
+func isZeroTime(v reflect.Value) bool {
+	return v.Interface().(time.Time).IsZero()
+}
+
+func isEmptyIPNet(v reflect.Value) bool {
+	return len(v.Interface().(net.IPNet).IP) == 0
+}
+
+func isEmptyURL(v reflect.Value) bool {
+	return v.Interface().(url.URL) == url.URL{}
+}
+
+// isInvalid reports whether one of the sql.Null types holds NULL.
+func isInvalid(v reflect.Value) bool {
+	return !v.FieldByName("Valid").Bool()
+}
+
+func copySlice(v reflect.Value) reflect.Value {
+	c := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+	reflect.Copy(c, v)
+	return c
+}
+
+func copyBigInt(v reflect.Value) reflect.Value {
+	return reflect.ValueOf(new(big.Int).Set(v.Interface().(*big.Int)))
+}
+
+func copyBigFloat(v reflect.Value) reflect.Value {
+	return reflect.ValueOf(new(big.Float).Copy(v.Interface().(*big.Float)))
+}
+
+func copyBigRat(v reflect.Value) reflect.Value {
+	return reflect.ValueOf(new(big.Rat).Set(v.Interface().(*big.Rat)))
+}
+
+func copyIPNet(v reflect.Value) reflect.Value {
+	n := v.Interface().(net.IPNet)
+	return reflect.ValueOf(net.IPNet{
+		IP:   append(net.IP(nil), n.IP...),
+		Mask: append(net.IPMask(nil), n.Mask...),
+	})
+}
@@ -0,0 +1,136 @@
+package transformers
+
+import (
+	"database/sql"
+	"math/big"
+	"net"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/davecgh/go-spew/spew"
+	"github.com/elephant-insurance/mergo"
+)
+
+type testConfig struct {
+	Started  time.Time
+	Deadline *time.Time
+	Limit    *big.Int
+	Ratio    *big.Rat
+	Address  net.IP
+	Network  net.IPNet
+	Endpoint url.URL
+	Proxy    *url.URL
+	Owner    sql.NullString
+	Retries  sql.NullInt64
+	Hosts    []string
+}
+
+func mustParseCIDR(s string) net.IPNet {
+	_, n, err := net.ParseCIDR(s)
+	if err != nil {
+		panic(err)
+	}
+	return *n
+}
+
+func newTestSrc() testConfig {
+	started := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	return testConfig{
+		Started:  started,
+		Deadline: &started,
+		Limit:    big.NewInt(100),
+		Ratio:    big.NewRat(1, 3),
+		Address:  net.ParseIP(`10.0.0.1`),
+		Network:  mustParseCIDR(`10.0.0.0/8`),
+		Endpoint: url.URL{Scheme: `https`, Host: `example.com`},
+		Proxy:    &url.URL{Scheme: `http`, Host: `proxy`},
+		Owner:    sql.NullString{String: `core`, Valid: true},
+		Retries:  sql.NullInt64{Int64: 3, Valid: true},
+		Hosts:    []string{`a`},
+	}
+}
+
+func TestStandard(t *testing.T) {
+	src := newTestSrc()
+	dst := testConfig{}
+	if err := mergo.Merge(&dst, src, mergo.WithTransformers(Standard())); err != nil {
+		t.Fatal(`error running Merge: ` + err.Error())
+	}
+	if !reflect.DeepEqual(dst, src) {
+		spew.Dump(dst)
+		t.Fatal(`Merge did not fill the empty dst`)
+	}
+	src.Limit.SetInt64(0)
+	src.Address[len(src.Address)-1] = 2
+	src.Network.IP[0] = 11
+	if dst.Limit.Int64() != 100 || dst.Address.String() != `10.0.0.1` || dst.Network.String() != `10.0.0.0/8` {
+		spew.Dump(dst)
+		t.Fatal(`dst shares memory with src`)
+	}
+}
+
+func TestStandardWithOverride(t *testing.T) {
+	dst := newTestSrc()
+	dst.Started = dst.Started.Add(time.Hour)
+	dst.Limit = big.NewInt(5)
+	dst.Address = net.ParseIP(`192.168.0.1`)
+	dst.Owner = sql.NullString{String: `ops`, Valid: true}
+	want := dst
+
+	// Without WithOverride set values are kept.
+	src := newTestSrc()
+	if err := mergo.Merge(&dst, src, mergo.WithTransformers(Standard())); err != nil {
+		t.Fatal(`error running Merge: ` + err.Error())
+	}
+	if !reflect.DeepEqual(dst, want) {
+		spew.Dump(dst)
+		t.Fatal(`Merge replaced values already set in dst`)
+	}
+
+	// With it they are replaced, except by empty values.
+	src.Retries = sql.NullInt64{}
+	src.Started = time.Time{}
+	if err := mergo.Merge(&dst, src, mergo.WithOverride, mergo.WithTransformers(Standard())); err != nil {
+		t.Fatal(`error running Merge: ` + err.Error())
+	}
+	want = newTestSrc()
+	want.Started = dst.Started
+	if !reflect.DeepEqual(dst, want) || dst.Started.IsZero() || !dst.Retries.Valid {
+		spew.Dump(dst)
+		t.Fatal(`Merge WithOverride did not replace the set values`)
+	}
+}
+
+func TestRegister(t *testing.T) {
+	type config struct {
+		Address net.IP
+		Name    string
+	}
+	upper := func(dst, src reflect.Value, settings mergo.MergeSettings) error {
+		dst.SetString(src.String() + `!`)
+		return nil
+	}
+	registry := Register(mergo.NewTransformerRegistry().RegisterPath(`Name`, upper))
+
+	dst := config{Name: `old`}
+	src := config{Address: net.ParseIP(`10.0.0.1`), Name: `new`}
+	if err := mergo.Merge(&dst, src, mergo.WithAppendSlice, mergo.WithTransformers(registry)); err != nil {
+		t.Fatal(`error running Merge: ` + err.Error())
+	}
+	if dst.Name != `new!` || !dst.Address.Equal(src.Address) {
+		spew.Dump(dst)
+		t.Fatal(`expected both the user and the built-in transformers to run`)
+	}
+
+	// An IP is not appended to like other slices.
+	src.Address = net.ParseIP(`10.0.0.2`)
+	if err := mergo.Merge(&dst, src, mergo.WithOverride, mergo.WithAppendSlice, mergo.WithTransformers(registry)); err != nil {
+		t.Fatal(`error running Merge: ` + err.Error())
+	}
+	if !dst.Address.Equal(src.Address) {
+		spew.Dump(dst)
+		t.Fatal(`expected the IP to be replaced`)
+	}
+}
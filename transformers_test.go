@@ -0,0 +1,163 @@
+package mergo
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/davecgh/go-spew/spew"
+)
+
+type transformersTestConfig struct {
+	RequiredConfig
+	Started  time.Time
+	Hosts    []string `config:"optional,append"`
+	Labels   []string
+	Database transformersTestDatabase
+}
+
+type transformersTestDatabase struct {
+	Host string
+	Name string
+}
+
+func TestTransformerRegistry(t *testing.T) {
+	replaceTime := func(dst, src reflect.Value, settings MergeSettings) error {
+		if !src.Interface().(time.Time).IsZero() && (dst.Interface().(time.Time).IsZero() || settings.Overwrite) {
+			dst.Set(src)
+		}
+		return nil
+	}
+	appendSlice := func(dst, src reflect.Value, settings MergeSettings) error {
+		dst.Set(reflect.AppendSlice(dst, src))
+		return nil
+	}
+	upperHost := func(dst, src reflect.Value, settings MergeSettings) error {
+		dst.SetString(strings.ToUpper(src.String()))
+		return nil
+	}
+	registry := NewTransformerRegistry().
+		RegisterType(reflect.TypeOf(time.Time{}), replaceTime, TransformEmpty).
+		RegisterTag(`append`, appendSlice).
+		RegisterPath(`Database.Host`, upperHost, TransformEmpty)
+
+	now := time.Now()
+	dst := transformersTestConfig{
+		Hosts:  []string{`a`},
+		Labels: []string{`x`},
+	}
+	src := transformersTestConfig{
+		RequiredConfig: RequiredConfig{LogLevel: `debug`},
+		Started:        now,
+		Hosts:          []string{`b`},
+		Labels:         []string{`y`},
+		Database:       transformersTestDatabase{Host: `db.local`, Name: `app`},
+	}
+	if err := Merge(&dst, src, WithOverride, WithTransformers(registry)); err != nil {
+		t.Fatal(`error running Merge: ` + err.Error())
+	}
+	want := transformersTestConfig{
+		RequiredConfig: RequiredConfig{LogLevel: `debug`},
+		Started:        now,
+		Hosts:          []string{`a`, `b`},
+		Labels:         []string{`y`},
+		Database:       transformersTestDatabase{Host: `DB.LOCAL`, Name: `app`},
+	}
+	if !reflect.DeepEqual(dst, want) {
+		spew.Dump(dst)
+		t.Fatal(`Merge did not apply the registered transformers`)
+	}
+}
+
+func TestTransformerRegistryThenMerge(t *testing.T) {
+	var seen []string
+	record := func(dst, src reflect.Value, settings MergeSettings) error {
+		seen = append(seen, dst.Field(0).String())
+		return nil
+	}
+	registry := NewTransformerRegistry().
+		RegisterType(reflect.TypeOf(transformersTestDatabase{}), record, TransformThenMerge)
+
+	dst := transformersTestConfig{Database: transformersTestDatabase{Host: `old`}}
+	src := transformersTestConfig{Database: transformersTestDatabase{Host: `new`, Name: `app`}}
+	if err := Merge(&dst, src, WithOverride, WithTransformers(registry)); err != nil {
+		t.Fatal(`error running Merge: ` + err.Error())
+	}
+	if !reflect.DeepEqual(seen, []string{`old`}) {
+		spew.Dump(seen)
+		t.Fatal(`expected the transformer to run once, before the merge`)
+	}
+	if dst.Database != src.Database {
+		spew.Dump(dst)
+		t.Fatal(`expected the default merge to run after the transformer`)
+	}
+
+	// A registry is also a Transformers, and by default its transformers only run on a non-empty dst.
+	calls := 0
+	count := func(dst, src reflect.Value, settings MergeSettings) error {
+		calls++
+		return nil
+	}
+	registry = NewTransformerRegistry().RegisterType(reflect.TypeOf([]string{}), count)
+	if fn := registry.Transformer(reflect.TypeOf([]string{})); fn == nil {
+		t.Fatal(`expected the registry to return the transformer registered for the type`)
+	}
+	dst = transformersTestConfig{Labels: []string{`x`}}
+	src = transformersTestConfig{Hosts: []string{`a`}, Labels: []string{`y`}}
+	if err := Merge(&dst, src, WithOverride, WithTransformers(registry)); err != nil {
+		t.Fatal(`error running Merge: ` + err.Error())
+	}
+	if calls != 1 || !reflect.DeepEqual(dst.Hosts, src.Hosts) || !reflect.DeepEqual(dst.Labels, []string{`x`}) {
+		spew.Dump(calls, dst)
+		t.Fatal(`expected the transformer to run for the non-empty dst field only`)
+	}
+}
+
+func TestTransformerRegistryMap(t *testing.T) {
+	calls := 0
+	count := func(dst, src reflect.Value, settings MergeSettings) error {
+		calls++
+		return nil
+	}
+	registry := NewTransformerRegistry().RegisterType(reflect.TypeOf(time.Time{}), count, TransformEmpty)
+	dst := map[string]interface{}{`name`: `svc`}
+	src := map[string]interface{}{
+		`database`: map[string]interface{}{`host`: `db`},
+		`server`:   &transformersTestDatabase{Host: `a`},
+	}
+	if err := Merge(&dst, src, WithTransformers(registry)); err != nil {
+		t.Fatal(`error running Merge: ` + err.Error())
+	}
+	want := map[string]interface{}{
+		`name`:     `svc`,
+		`database`: map[string]interface{}{`host`: `db`},
+		`server`:   &transformersTestDatabase{Host: `a`},
+	}
+	if calls != 0 || !reflect.DeepEqual(dst, want) {
+		spew.Dump(calls, dst)
+		t.Fatal(`expected the keys missing from dst to be copied`)
+	}
+}
+
+func TestTransformerRegistryConfig(t *testing.T) {
+	var overwrite []bool
+	record := func(dst, src reflect.Value, settings MergeSettings) error {
+		overwrite = append(overwrite, settings.Overwrite)
+		return nil
+	}
+	registry := NewTransformerRegistry().RegisterType(reflect.TypeOf([]string{}), record)
+	dst := transformersTestConfig{Labels: []string{`x`}}
+	if err := Merge(&dst, transformersTestConfig{Labels: []string{`y`}}, WithOverride, WithTransformers(registry)); err != nil {
+		t.Fatal(`error running Merge: ` + err.Error())
+	}
+	// called as a Transformers, outside Merge, there is no configuration to pass on
+	fn := registry.Transformer(reflect.TypeOf([]string{}))
+	if err := fn(reflect.ValueOf(&dst.Labels).Elem(), reflect.ValueOf([]string{`y`})); err != nil {
+		t.Fatal(`error running the transformer: ` + err.Error())
+	}
+	if !reflect.DeepEqual(overwrite, []bool{true, false}) {
+		spew.Dump(overwrite)
+		t.Fatal(`expected Merge to pass its configuration, and the Transformers adapter a zero one`)
+	}
+}
@@ -0,0 +1,253 @@
+package mergo
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultPollInterval is how often a Watcher checks its files unless WithPollInterval is used.
+const DefaultPollInterval = 5 * time.Second
+
+// ErrInvalidPollInterval is returned by NewWatcher for a poll interval that is not positive.
+var ErrInvalidPollInterval = errors.New("poll interval must be positive")
+
+// Watcher reloads configuration when the files it watches change. It polls their
+// modification times and sizes, so it needs no file system notifications. Every reload
+// runs the whole load again on a copy of the value the Watcher started from, validates
+// the result and, if it is valid, publishes it to Current and the subscribers. A failed
+// reload keeps the current value.
+type Watcher struct {
+	base     interface{}
+	load     func(dst interface{}) error
+	paths    []string
+	interval time.Duration
+	validate func(v interface{}) error
+	onError  func(err error)
+
+	current     atomic.Value
+	stamps      string
+	reloadMu    sync.Mutex
+	mu          sync.Mutex
+	subscribers []func(old, new interface{})
+	stop        chan struct{}
+	done        chan struct{}
+}
+
+// WithPollInterval will make the Watcher check its files every d.
+func WithPollInterval(d time.Duration) func(*Watcher) {
+	return func(w *Watcher) {
+		w.interval = d
+	}
+}
+
+// WithValidator will make the Watcher publish only the values for which fn returns nil.
+func WithValidator(fn func(v interface{}) error) func(*Watcher) {
+	return func(w *Watcher) {
+		w.validate = fn
+	}
+}
+
+// WithReloadErrorHandler will make the Watcher report to fn the reloads that failed.
+func WithReloadErrorHandler(fn func(err error)) func(*Watcher) {
+	return func(w *Watcher) {
+		w.onError = fn
+	}
+}
+
+// NewWatcher returns a Watcher running load on copies of dst, a pointer, whenever a file
+// or directory in paths changes. A directory changes when a file in it is added, removed
+// or modified. The first load is run right away, on dst itself, and must succeed and be
+// valid; call Start to begin watching.
+func NewWatcher(dst interface{}, load func(dst interface{}) error, paths []string, opts ...func(*Watcher)) (*Watcher, error) {
+	if dst == nil {
+		return nil, ErrNilArguments
+	}
+	if v := reflect.ValueOf(dst); v.Kind() != reflect.Ptr || v.IsNil() {
+		return nil, ErrNonPointerAgument
+	}
+	w := &Watcher{
+		base:     Clone(dst),
+		load:     load,
+		paths:    paths,
+		interval: DefaultPollInterval,
+	}
+	for _, opt := range opts {
+		opt(w)
+	}
+	if w.interval <= 0 {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidPollInterval, w.interval)
+	}
+	w.stamps = fileStamps(paths)
+	if err := w.run(dst); err != nil {
+		return nil, err
+	}
+	w.current.Store(dst)
+	return w, nil
+}
+
+// Watch returns a Watcher loading the files in paths into dst with l.
+func (l *Loader) Watch(dst interface{}, paths []string, opts ...func(*Watcher)) (*Watcher, error) {
+	return NewWatcher(dst, func(v interface{}) error {
+		return l.Load(v, paths...)
+	}, paths, opts...)
+}
+
+// WatchDir returns a Watcher loading the files of dir into dst with l, as LoadDir does.
+// A file named by OverrideConfigPath outside dir is not watched.
+func (l *Loader) WatchDir(dst interface{}, dir, env, region string, opts ...func(*Watcher)) (*Watcher, error) {
+	return NewWatcher(dst, func(v interface{}) error {
+		return l.LoadDir(v, dir, env, region)
+	}, []string{dir}, opts...)
+}
+
+// Current returns the last valid value loaded, a pointer of the type passed to NewWatcher.
+// It must not be modified, since other goroutines may be reading it.
+func (w *Watcher) Current() interface{} {
+	return w.current.Load()
+}
+
+// Subscribe makes the Watcher call fn with the previous and the new value after each
+// successful reload.
+func (w *Watcher) Subscribe(fn func(old, new interface{})) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.subscribers = append(w.subscribers, fn)
+}
+
+// Start begins polling the files in a new goroutine. It does nothing if the Watcher
+// is already started.
+func (w *Watcher) Start() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.stop != nil {
+		return
+	}
+	w.stop, w.done = make(chan struct{}), make(chan struct{})
+	go w.poll(w.stop, w.done)
+}
+
+// Stop stops polling without waiting for a reload in progress, so it may be called from
+// a subscriber too. Call Wait to wait for that reload to finish.
+func (w *Watcher) Stop() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.stop != nil {
+		close(w.stop)
+		w.stop = nil
+	}
+}
+
+// Wait waits until the poll goroutine started last has exited, after Stop and after
+// finishing a reload in progress. It returns at once if the Watcher was never started.
+// Wait must not be called from the validator, the error handler or a subscriber, since
+// it would wait for the reload calling it.
+func (w *Watcher) Wait() {
+	w.mu.Lock()
+	done := w.done
+	w.mu.Unlock()
+	if done != nil {
+		<-done
+	}
+}
+
+// Reload loads, validates and publishes the configuration now, whether or not the
+// files changed. On failure, the current value is kept and the error returned.
+func (w *Watcher) Reload() error {
+	w.reloadMu.Lock()
+	defer w.reloadMu.Unlock()
+	return w.reload()
+}
+
+// reload does the work of Reload, with reloadMu held.
+func (w *Watcher) reload() error {
+	w.stamps = fileStamps(w.paths)
+	next := Clone(w.base)
+	if err := w.run(next); err != nil {
+		if w.onError != nil {
+			w.onError(err)
+		}
+		return err
+	}
+	old := w.current.Load()
+	w.current.Store(next)
+	w.mu.Lock()
+	subscribers := append([]func(old, new interface{}){}, w.subscribers...)
+	w.mu.Unlock()
+	for _, fn := range subscribers {
+		fn(old, next)
+	}
+	return nil
+}
+
+func (w *Watcher) run(dst interface{}) error {
+	if err := w.load(dst); err != nil {
+		return err
+	}
+	if w.validate != nil {
+		if err := w.validate(dst); err != nil {
+			return fmt.Errorf("invalid configuration: %w", err)
+		}
+	}
+	return nil
+}
+
+func (w *Watcher) poll(stop, done chan struct{}) {
+	defer close(done)
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			// a Reload running in another goroutine may be calling Wait, which waits
+			// for this goroutine, so the tick is skipped rather than waiting for it
+			if !w.reloadMu.TryLock() {
+				continue
+			}
+			if fileStamps(w.paths) != w.stamps {
+				_ = w.reload()
+			}
+			w.reloadMu.Unlock()
+		}
+	}
+}
+
+// fileStamps describes the modification times and sizes of paths, and of the files
+// of those that are directories, so that any change gives a different result.
+func fileStamps(paths []string) string {
+	var stamps []string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			stamps = append(stamps, path+` missing`)
+			continue
+		}
+		stamps = append(stamps, fileStamp(path, info))
+		if !info.IsDir() {
+			continue
+		}
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			continue
+		}
+		for _, e := range entries {
+			if info, err := e.Info(); err == nil && !info.IsDir() {
+				stamps = append(stamps, fileStamp(filepath.Join(path, e.Name()), info))
+			}
+		}
+	}
+	sort.Strings(stamps)
+	return fmt.Sprint(stamps)
+}
+
+func fileStamp(path string, info os.FileInfo) string {
+	return fmt.Sprintf("%s %d %d", path, info.ModTime().UnixNano(), info.Size())
+}
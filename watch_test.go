@@ -0,0 +1,189 @@
+package mergo
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func writeWatchTestFile(t *testing.T, path, content string, mtime time.Time) {
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(`error writing file: ` + err.Error())
+	}
+	// make the change visible even on file systems with a coarse mtime
+	if err := os.Chtimes(path, mtime, mtime); err != nil {
+		t.Fatal(`error setting file times: ` + err.Error())
+	}
+}
+
+func TestWatcher(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, `app.yml`)
+	start := time.Now().Add(-time.Hour)
+	writeWatchTestFile(t, path, "Port: 8080\n", start)
+
+	validate := func(v interface{}) error {
+		if v.(*loadTestConfig).Port == 0 {
+			return errors.New(`port is required`)
+		}
+		return nil
+	}
+	var reloadErr error
+	cfg := &loadTestConfig{Database: loadTestDatabase{Name: `default`}}
+	w, err := NewLoader().Watch(cfg, []string{path},
+		WithPollInterval(10*time.Millisecond),
+		WithValidator(validate),
+		WithReloadErrorHandler(func(err error) { reloadErr = err }),
+	)
+	if err != nil {
+		t.Fatal(`error creating Watcher: ` + err.Error())
+	}
+	if w.Current().(*loadTestConfig).Port != 8080 {
+		t.Fatal(`expected the first load to be published`)
+	}
+
+	changes := make(chan *loadTestConfig, 1)
+	w.Subscribe(func(old, new interface{}) {
+		changes <- new.(*loadTestConfig)
+	})
+	w.Start()
+	defer w.Stop()
+
+	writeWatchTestFile(t, path, "Port: 9090\nDatabase:\n  Host: db\n", start.Add(time.Minute))
+	select {
+	case next := <-changes:
+		if next.Port != 9090 || next.Database.Host != `db` || next.Database.Name != `default` {
+			t.Fatalf(`unexpected reloaded value %+v`, next)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal(`the Watcher did not reload the changed file`)
+	}
+	w.Stop()
+
+	// An invalid file is reported and the last valid value kept.
+	writeWatchTestFile(t, path, "Database:\n  Host: other\n", start.Add(2*time.Minute))
+	if err = w.Reload(); err == nil || reloadErr != err {
+		t.Fatalf(`expected the validation error to be returned and reported, got %v`, err)
+	}
+	if current := w.Current().(*loadTestConfig); current.Port != 9090 || current.Database.Host != `db` {
+		t.Fatalf(`expected the last valid value to be kept, got %+v`, current)
+	}
+}
+
+func TestWatcherDir(t *testing.T) {
+	dir := t.TempDir()
+	start := time.Now().Add(-time.Hour)
+	writeWatchTestFile(t, filepath.Join(dir, `base.yml`), "Environment: prod\nPort: 8080\n", start)
+
+	var cfg loadTestConfig
+	w, err := NewLoader().WatchDir(&cfg, dir, ``, ``)
+	if err != nil {
+		t.Fatal(`error creating Watcher: ` + err.Error())
+	}
+	before := fileStamps(w.paths)
+	writeWatchTestFile(t, filepath.Join(dir, `prod.yml`), "Port: 9090\n", start)
+	if fileStamps(w.paths) == before {
+		t.Fatal(`expected a new file in the directory to be a change`)
+	}
+	if err = w.Reload(); err != nil {
+		t.Fatal(`error reloading: ` + err.Error())
+	}
+	if w.Current().(*loadTestConfig).Port != 9090 {
+		t.Fatal(`expected the new environment file to be loaded`)
+	}
+}
+
+func TestWatcherStopFromSubscriber(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, `app.yml`)
+	start := time.Now().Add(-time.Hour)
+	writeWatchTestFile(t, path, "Port: 8080\n", start)
+
+	w, err := NewLoader().Watch(&loadTestConfig{}, []string{path}, WithPollInterval(10*time.Millisecond))
+	if err != nil {
+		t.Fatal(`error creating Watcher: ` + err.Error())
+	}
+	stopped := make(chan struct{})
+	w.Subscribe(func(old, new interface{}) {
+		w.Stop()
+		close(stopped)
+	})
+	w.Start()
+
+	writeWatchTestFile(t, path, "Port: 9090\n", start.Add(time.Minute))
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal(`Stop called from a subscriber did not return`)
+	}
+	waited := make(chan struct{})
+	go func() {
+		w.Wait()
+		close(waited)
+	}()
+	select {
+	case <-waited:
+	case <-time.After(5 * time.Second):
+		t.Fatal(`the poll goroutine did not stop`)
+	}
+}
+
+func TestWatcherWaitForSubscriber(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, `app.yml`)
+	start := time.Now().Add(-time.Hour)
+	writeWatchTestFile(t, path, "Port: 8080\n", start)
+
+	w, err := NewLoader().Watch(&loadTestConfig{}, []string{path}, WithPollInterval(10*time.Millisecond))
+	if err != nil {
+		t.Fatal(`error creating Watcher: ` + err.Error())
+	}
+	called, release := make(chan struct{}), make(chan struct{})
+	var finished int32
+	w.Subscribe(func(old, new interface{}) {
+		close(called)
+		<-release
+		atomic.StoreInt32(&finished, 1)
+	})
+	w.Start()
+
+	writeWatchTestFile(t, path, "Port: 9090\n", start.Add(time.Minute))
+	select {
+	case <-called:
+	case <-time.After(5 * time.Second):
+		t.Fatal(`the Watcher did not reload the changed file`)
+	}
+	stopped := make(chan struct{})
+	go func() {
+		w.Stop()
+		w.Wait()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+		t.Fatal(`Wait returned while a subscriber was running`)
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(release)
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal(`Wait did not return after the reload finished`)
+	}
+	if atomic.LoadInt32(&finished) != 1 {
+		t.Fatal(`Wait returned before the subscriber finished`)
+	}
+}
+
+func TestWatcherInvalidPollInterval(t *testing.T) {
+	path := filepath.Join(t.TempDir(), `app.yml`)
+	writeWatchTestFile(t, path, "Port: 8080\n", time.Now())
+	for _, d := range []time.Duration{0, -time.Second} {
+		if _, err := NewLoader().Watch(&loadTestConfig{}, []string{path}, WithPollInterval(d)); !errors.Is(err, ErrInvalidPollInterval) {
+			t.Fatalf(`expected %v for interval %s, got %v`, ErrInvalidPollInterval, d, err)
+		}
+	}
+}